@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"final-project/database"
+	"final-project/helpers"
+	"final-project/lockout"
+	"final-project/models"
+	"final-project/policy"
+	"final-project/revocation"
+	"net/http"
+	"strconv"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type unlockInput struct {
+	IP string `json:"ip" form:"ip"`
+}
+
+// Unlock godoc
+// @Summary      Unlock a user account
+// @Description  clear the failed login counters of a user and, when given, of an IP address
+// @Tags         Admin
+// @Param        userId   path      int  true  "User ID"
+// @Param        ip formData string false "IP address to unlock"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /admin/users/{userId}/unlock [post]
+func AdminUnlockUser(c *gin.Context) {
+	db := database.GetDB()
+	contentType := helpers.GetContentType(c)
+	input := unlockInput{}
+	user := models.User{}
+
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Invalid user id",
+		})
+
+		return
+	}
+
+	if contentType == appJSON {
+		c.ShouldBindJSON(&input)
+	} else {
+		c.ShouldBind(&input)
+	}
+
+	if err := db.Select("id", "email").First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "User not found",
+		})
+
+		return
+	}
+
+	guard := lockout.GetGuard()
+
+	if err := guard.UnlockAccount(user.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to unlock account",
+		})
+
+		return
+	}
+
+	if input.IP != "" {
+		if err := guard.UnlockIP(input.IP); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Internal Server Error",
+				"message": "Failed to unlock IP address",
+			})
+
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The account has been successfully unlocked",
+	})
+}
+
+type roleInput struct {
+	Role string `json:"role" form:"role"`
+}
+
+// UpdateRole godoc
+// @Summary      Change a user's role
+// @Description  set the role of a user to user, moderator or admin; the user's tokens are revoked so the new role applies immediately
+// @Tags         Admin
+// @Param        userId   path      int  true  "User ID"
+// @Param        role formData string true "Role"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /admin/users/{userId}/role [put]
+func AdminUpdateRole(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	contentType := helpers.GetContentType(c)
+	actor := policy.ActorFromClaims(userData)
+	input := roleInput{}
+	user := models.User{}
+
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Invalid user id",
+		})
+
+		return
+	}
+
+	if contentType == appJSON {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		if err := c.ShouldBind(&input); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if !models.ValidRole(input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Role must be one of user, moderator or admin",
+		})
+
+		return
+	}
+
+	if uint(userID) == actor.ID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "You cannot change your own role",
+		})
+
+		return
+	}
+
+	if err := db.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "User not found",
+		})
+
+		return
+	}
+
+	previous := user.Role
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("role", input.Role).Error; err != nil {
+			return err
+		}
+
+		return policy.RecordRoleChange(tx, actor, user.ID, previous, input.Role)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to change role",
+		})
+
+		return
+	}
+
+	if err := revocation.RevokeUser(db, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to revoke user tokens",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":       user.ID,
+		"username": user.Username,
+		"role":     user.Role,
+	})
+}
+
+// AuditLogs godoc
+// @Summary      Fetch audit logs
+// @Description  get the most recent moderator and admin overrides and role changes
+// @Tags         Admin
+// @Param        limit query int false "Number of entries, at most 200"
+// @Success      200  {object}  []models.AuditLog
+// @Security    BearerAuth
+// @Router       /admin/audit-logs [get]
+func AdminAuditLogs(c *gin.Context) {
+	db := database.GetDB()
+	logs := []models.AuditLog{}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	if err := db.Order("created_at desc").Limit(limit).Find(&logs).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, logs)
+}
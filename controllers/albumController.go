@@ -0,0 +1,688 @@
+package controllers
+
+import (
+	"errors"
+	"final-project/database"
+	"final-project/helpers"
+	"final-project/listquery"
+	"final-project/models"
+	"net/http"
+	"strconv"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type albumInput struct {
+	Title        string `json:"title" form:"title"`
+	Description  string `json:"description" form:"description"`
+	CoverPhotoId *uint  `json:"cover_photo_id" form:"cover_photo_id"`
+}
+
+type albumPhotoInput struct {
+	PhotoId uint `json:"photo_id" form:"photo_id"`
+}
+
+type albumOrderInput struct {
+	PhotoIds []uint `json:"photo_ids" form:"photo_ids"`
+}
+
+type albumPhotoCount struct {
+	AlbumId uint
+	Count   int64
+}
+
+var (
+	errNotOwnPhoto   = errors.New("Only photos of the album owner can be added to the album")
+	errAlbumOrder    = errors.New("photo_ids must list every photo of the album exactly once")
+	errAlreadyMember = errors.New("The photo is already in the album")
+)
+
+// AlbumCreate godoc
+// @Summary      Create an album
+// @Description  create an empty album; photos are added with POST /albums/{albumId}/photos
+// @Tags         Album
+// @Param        title formData string true "Album's Title"
+// @Param        description formData string false "Album's Description"
+// @Param        cover_photo_id formData int false "ID of one of your photos to show as the cover"
+// @Success      201  {object}  models.Album
+// @Security    BearerAuth
+// @Router       /albums        [post]
+func AlbumCreate(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	contentType := helpers.GetContentType(c)
+	userID := uint(userData["id"].(float64))
+	input := albumInput{}
+
+	if contentType == appJSON {
+		c.ShouldBindJSON(&input)
+	} else {
+		c.ShouldBind(&input)
+	}
+
+	album := models.Album{
+		Title:       input.Title,
+		Description: input.Description,
+		UserId:      userID,
+	}
+
+	if input.CoverPhotoId != nil && *input.CoverPhotoId != 0 {
+		if err := checkPhotoOwner(db, userID, *input.CoverPhotoId); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Bad Request",
+				"message": err.Error(),
+			})
+			return
+		}
+
+		album.CoverPhotoId = input.CoverPhotoId
+	}
+
+	if err := db.Create(&album).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"id":             album.ID,
+		"title":          album.Title,
+		"description":    album.Description,
+		"cover_photo_id": album.CoverPhotoId,
+		"user_id":        album.UserId,
+		"created_at":     album.CreatedAt,
+	})
+}
+
+// AlbumGetByID godoc
+// @Summary      Get an album by ID
+// @Description  Retrieve an album with its photos in album order
+// @Tags         Album
+// @Param        albumId   path      int  true  "Album ID"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /albums/{albumId}   [get]
+func AlbumGetByID(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	userID := uint(userData["id"].(float64))
+	album := models.Album{}
+	photos := []models.Photo{}
+	data := []gin.H{}
+
+	if err := db.Preload("User").First(&album, c.Param("albumId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "Album not found",
+		})
+		return
+	}
+
+	err := db.
+		Joins("JOIN album_photos ON album_photos.photo_id = photos.id").
+		Where("album_photos.album_id = ?", album.ID).
+		Order("album_photos.position, photos.id").
+		Preload("User").
+		Preload("Renditions", orderRenditions).
+		Find(&photos).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	photoIDs := make([]uint, len(photos))
+	for i := range photos {
+		photoIDs[i] = photos[i].ID
+	}
+
+	likeCounts, likedByMe, err := loadLikes(db, userID, photoIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to load likes",
+		})
+		return
+	}
+
+	var cover *models.Photo
+	for i, photo := range photos {
+		if album.CoverPhotoId != nil && photo.ID == *album.CoverPhotoId || cover == nil && i == 0 {
+			cover = &photos[i]
+		}
+
+		data = append(data, gin.H{
+			"id":          photo.ID,
+			"title":       photo.Title,
+			"caption":     photo.Caption,
+			"photo_url":   photo.PhotoUrl,
+			"status":      photo.Status,
+			"renditions":  renditionList(photo.Renditions),
+			"metadata":    photoMetadata(photo, userID),
+			"user_id":     photo.UserId,
+			"created_at":  photo.CreatedAt,
+			"like_count":  likeCounts[photo.ID],
+			"liked_by_me": likedByMe[photo.ID],
+		})
+	}
+
+	// A cover that is not in the album is loaded on its own.
+	if album.CoverPhotoId != nil && (cover == nil || cover.ID != *album.CoverPhotoId) {
+		covers, _, err := loadAlbumCovers(db, []models.Album{album})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Internal Server Error",
+				"message": "Failed to load the cover photo",
+			})
+			return
+		}
+
+		if photo, ok := covers[album.ID]; ok {
+			cover = &photo
+		}
+	}
+
+	payload := albumPayload(album, cover, int64(len(photos)))
+	payload["photos"] = data
+
+	if album.User != nil {
+		payload["User"] = gin.H{
+			"id":       album.User.ID,
+			"username": album.User.Username,
+		}
+	}
+
+	c.JSON(http.StatusOK, payload)
+}
+
+// UserAlbums godoc
+// @Summary      Fetch the albums of a user
+// @Description  get a user's albums one page at a time, newest first by default
+// @Tags         Album
+// @Param        userId   path      int  true  "User ID"
+// @Param        limit   query      int  false  "Page size, at most 100"
+// @Param        cursor   query      string  false  "next_cursor or prev_cursor of another page"
+// @Param        sort   query      string  false  "created_at, updated_at or id, prefixed with - for descending order (default -created_at)"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/{userId}/albums [get]
+func UserAlbums(c *gin.Context) {
+	db := database.GetDB()
+	albums := []models.Album{}
+	data := []gin.H{}
+
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Invalid user id",
+		})
+		return
+	}
+
+	if err := db.Select("id").First(&models.User{}, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "User not found",
+		})
+		return
+	}
+
+	query, err := listquery.Parse(c, listquery.Options{Table: "albums"})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := query.Apply(db.Where("albums.user_id = ?", userID)).Find(&albums).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	albums, pagination := listquery.Page(query, albums, func(a models.Album) listquery.Key {
+		return listquery.KeyOf(a.GormModel)
+	})
+
+	covers, counts, err := loadAlbumCovers(db, albums)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to load album covers",
+		})
+		return
+	}
+
+	for _, album := range albums {
+		var cover *models.Photo
+		if photo, ok := covers[album.ID]; ok {
+			cover = &photo
+		}
+
+		data = append(data, albumPayload(album, cover, counts[album.ID]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       data,
+		"pagination": pagination,
+	})
+}
+
+// AlbumUpdate godoc
+// @Summary      Update an album
+// @Description  update the title, description and cover of an album; a cover_photo_id of 0 removes the cover
+// @Tags         Album
+// @Param        albumId   path      int  true  "Album ID"
+// @Param        title formData string true "Album's Title"
+// @Param        description formData string false "Album's Description"
+// @Param        cover_photo_id formData int false "ID of one of the owner's photos to show as the cover"
+// @Success      200  {object}  models.Album
+// @Security    BearerAuth
+// @Router       /albums/{albumId} [put]
+func AlbumUpdate(c *gin.Context) {
+	db := database.GetDB()
+	contentType := helpers.GetContentType(c)
+	input := albumInput{}
+	album := models.Album{}
+
+	if contentType == appJSON {
+		c.ShouldBindJSON(&input)
+	} else {
+		c.ShouldBind(&input)
+	}
+
+	if err := db.First(&album, c.Param("albumId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "Album not found",
+		})
+		return
+	}
+
+	changes := map[string]interface{}{
+		"title":       input.Title,
+		"description": input.Description,
+	}
+
+	if input.CoverPhotoId != nil {
+		changes["cover_photo_id"] = nil
+
+		if *input.CoverPhotoId != 0 {
+			// Moderators may edit the album, but the cover stays one of the owner's photos.
+			if err := checkPhotoOwner(db, album.UserId, *input.CoverPhotoId); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":   "Bad Request",
+					"message": err.Error(),
+				})
+				return
+			}
+
+			changes["cover_photo_id"] = *input.CoverPhotoId
+		}
+	}
+
+	// BeforeUpdate validates the model, not the map.
+	album.Title = input.Title
+
+	err := db.Model(&album).Updates(changes).Error
+
+	if err == nil {
+		err = db.First(&album, album.ID).Error
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":             album.ID,
+		"title":          album.Title,
+		"description":    album.Description,
+		"cover_photo_id": album.CoverPhotoId,
+		"user_id":        album.UserId,
+		"updated_at":     album.UpdatedAt,
+	})
+}
+
+// AlbumDelete godoc
+// @Summary      Delete an album
+// @Description  delete an album by ID; its photos are kept
+// @Tags         Album
+// @Param        albumId   path      int  true  "Album ID"
+// @Success      200  {string}  string
+// @Security    BearerAuth
+// @Router       /albums/{albumId}   [delete]
+func AlbumDelete(c *gin.Context) {
+	db := database.GetDB()
+
+	albumID, _ := strconv.Atoi(c.Param("albumId"))
+
+	if err := db.Delete(&models.Album{}, albumID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Your album has been successfully deleted",
+	})
+}
+
+// AlbumAddPhoto godoc
+// @Summary      Add a photo to an album
+// @Description  append one of the owner's photos to the end of an album
+// @Tags         Album
+// @Param        albumId   path      int  true  "Album ID"
+// @Param        photo_id formData int true "Photo ID"
+// @Success      201  {object}  models.AlbumPhoto
+// @Security    BearerAuth
+// @Router       /albums/{albumId}/photos [post]
+func AlbumAddPhoto(c *gin.Context) {
+	db := database.GetDB()
+	contentType := helpers.GetContentType(c)
+	input := albumPhotoInput{}
+	album := models.Album{}
+
+	if contentType == appJSON {
+		c.ShouldBindJSON(&input)
+	} else {
+		c.ShouldBind(&input)
+	}
+
+	if err := db.First(&album, c.Param("albumId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "Album not found",
+		})
+		return
+	}
+
+	member := models.AlbumPhoto{AlbumId: album.ID, PhotoId: input.PhotoId}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := checkPhotoOwner(tx, album.UserId, input.PhotoId); err != nil {
+			return err
+		}
+
+		// Lock the album so concurrent additions get distinct positions.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Album{}, album.ID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.AlbumPhoto{}).Select("COALESCE(MAX(position), -1) + 1").Where("album_id = ?", album.ID).Scan(&member.Position).Error; err != nil {
+			return err
+		}
+
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&member)
+		if res.Error == nil && res.RowsAffected == 0 {
+			return errAlreadyMember
+		}
+
+		return res.Error
+	})
+
+	if errors.Is(err, errAlreadyMember) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Conflict",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// AlbumRemovePhoto godoc
+// @Summary      Remove a photo from an album
+// @Description  take a photo out of an album; the photo itself is kept
+// @Tags         Album
+// @Param        albumId   path      int  true  "Album ID"
+// @Param        photoId   path      int  true  "Photo ID"
+// @Success      200  {string}  string
+// @Security    BearerAuth
+// @Router       /albums/{albumId}/photos/{photoId} [delete]
+func AlbumRemovePhoto(c *gin.Context) {
+	db := database.GetDB()
+
+	albumID, _ := strconv.Atoi(c.Param("albumId"))
+	photoID, err := strconv.Atoi(c.Param("photoId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Invalid photo id",
+		})
+		return
+	}
+
+	res := db.Where("album_id = ? AND photo_id = ?", albumID, photoID).Delete(&models.AlbumPhoto{})
+
+	if res.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": res.Error.Error(),
+		})
+		return
+	}
+
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "The photo is not in the album",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The photo has been successfully removed from the album",
+	})
+}
+
+// AlbumReorder godoc
+// @Summary      Reorder an album
+// @Description  set the order of the photos of an album; photo_ids must list every photo of the album exactly once
+// @Tags         Album
+// @Accept       json
+// @Param        albumId   path      int  true  "Album ID"
+// @Param        photo_ids body []int true "Photo IDs in their new order"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /albums/{albumId}/photos [put]
+func AlbumReorder(c *gin.Context) {
+	db := database.GetDB()
+	contentType := helpers.GetContentType(c)
+	input := albumOrderInput{}
+
+	albumID, _ := strconv.Atoi(c.Param("albumId"))
+
+	if contentType == appJSON {
+		c.ShouldBindJSON(&input)
+	} else {
+		c.ShouldBind(&input)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Album{}, albumID).Error; err != nil {
+			return err
+		}
+
+		current := []uint{}
+		if err := tx.Model(&models.AlbumPhoto{}).Where("album_id = ?", albumID).Pluck("photo_id", &current).Error; err != nil {
+			return err
+		}
+
+		if len(current) != len(input.PhotoIds) {
+			return errAlbumOrder
+		}
+
+		members := map[uint]bool{}
+		for _, id := range current {
+			members[id] = true
+		}
+
+		for _, id := range input.PhotoIds {
+			if !members[id] {
+				return errAlbumOrder
+			}
+
+			delete(members, id)
+		}
+
+		for position, id := range input.PhotoIds {
+			if err := tx.Model(&models.AlbumPhoto{}).Where("album_id = ? AND photo_id = ?", albumID, id).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"album_id":  albumID,
+		"photo_ids": input.PhotoIds,
+	})
+}
+
+// checkPhotoOwner makes sure a photo exists and belongs to userID.
+func checkPhotoOwner(db *gorm.DB, userID, photoID uint) error {
+	photo := models.Photo{}
+
+	if err := db.Select("id", "user_id").First(&photo, photoID).Error; err != nil {
+		return errors.New("Photo not found")
+	}
+
+	if photo.UserId != userID {
+		return errNotOwnPhoto
+	}
+
+	return nil
+}
+
+// loadAlbumCovers returns the cover of each album, falling back to its first
+// photo, and the number of photos per album, with one query each.
+func loadAlbumCovers(db *gorm.DB, albums []models.Album) (map[uint]models.Photo, map[uint]int64, error) {
+	covers := map[uint]models.Photo{}
+	counts := map[uint]int64{}
+
+	if len(albums) == 0 {
+		return covers, counts, nil
+	}
+
+	albumIDs := make([]uint, len(albums))
+	for i := range albums {
+		albumIDs[i] = albums[i].ID
+	}
+
+	rows := []albumPhotoCount{}
+	if err := db.Model(&models.AlbumPhoto{}).Select("album_id, count(*) AS count").Where("album_id IN ?", albumIDs).Group("album_id").Scan(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.AlbumId] = row.Count
+	}
+
+	firsts := []models.AlbumPhoto{}
+	err := db.Raw("SELECT DISTINCT ON (album_id) album_id, photo_id FROM album_photos WHERE album_id IN ? ORDER BY album_id, position, photo_id", albumIDs).Scan(&firsts).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	coverIDs := map[uint]uint{}
+	for _, first := range firsts {
+		coverIDs[first.AlbumId] = first.PhotoId
+	}
+
+	for _, album := range albums {
+		if album.CoverPhotoId != nil {
+			coverIDs[album.ID] = *album.CoverPhotoId
+		}
+	}
+
+	if len(coverIDs) == 0 {
+		return covers, counts, nil
+	}
+
+	photoIDs := make([]uint, 0, len(coverIDs))
+	for _, id := range coverIDs {
+		photoIDs = append(photoIDs, id)
+	}
+
+	photos := []models.Photo{}
+	if err := db.Preload("Renditions", orderRenditions).Where("id IN ?", photoIDs).Find(&photos).Error; err != nil {
+		return nil, nil, err
+	}
+
+	byID := map[uint]models.Photo{}
+	for _, photo := range photos {
+		byID[photo.ID] = photo
+	}
+
+	for albumID, photoID := range coverIDs {
+		if photo, ok := byID[photoID]; ok {
+			covers[albumID] = photo
+		}
+	}
+
+	return covers, counts, nil
+}
+
+// albumPayload is the album object of album responses. cover may be nil for
+// empty albums.
+func albumPayload(album models.Album, cover *models.Photo, photoCount int64) gin.H {
+	payload := gin.H{
+		"id":             album.ID,
+		"title":          album.Title,
+		"description":    album.Description,
+		"cover_photo_id": album.CoverPhotoId,
+		"cover_photo":    nil,
+		"photo_count":    photoCount,
+		"user_id":        album.UserId,
+		"created_at":     album.CreatedAt,
+		"updated_at":     album.UpdatedAt,
+	}
+
+	if cover != nil {
+		payload["cover_photo"] = gin.H{
+			"id":         cover.ID,
+			"photo_url":  cover.PhotoUrl,
+			"renditions": renditionList(cover.Renditions),
+		}
+	}
+
+	return payload
+}
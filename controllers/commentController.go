@@ -3,6 +3,7 @@ package controllers
 import (
 	"final-project/database"
 	"final-project/helpers"
+	"final-project/listquery"
 	"final-project/models"
 	"net/http"
 	"strconv"
@@ -57,17 +58,34 @@ func CommentCreate(c *gin.Context) {
 
 // Fetch godoc
 // @Summary      Fetch comments
-// @Description  get comments
+// @Description  get comments one page at a time
 // @Tags         Comment
-// @Success      200	{object}	[]models.Comment
+// @Param        limit   query      int  false  "Page size, at most 100"
+// @Param        cursor   query      string  false  "next_cursor or prev_cursor of another page"
+// @Param        sort   query      string  false  "created_at, updated_at or id, prefixed with - for descending order (default -created_at)"
+// @Param        user_id   query      int  false  "Only items with this user id"
+// @Param        photo_id   query      int  false  "Only items with this photo id"
+// @Param        created_after   query      string  false  "Only items created at or after this RFC 3339 time"
+// @Param        created_before   query      string  false  "Only items created before this RFC 3339 time"
+// @Success      200	{object}	map[string]interface{}
 // @Security    BearerAuth
 // @Router       /comments      [get]
 func CommentList(c *gin.Context) {
 	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
 	Comments := []models.Comment{}
-	var data []interface{}
+	data := []interface{}{}
+
+	query, err := listquery.Parse(c, listquery.Options{Table: "comments", Filters: map[string]string{"user_id": "comments.user_id", "photo_id": "comments.photo_id"}})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+		return
+	}
 
-	err := db.Model(&models.Comment{}).Preload("User").Preload("Photo").Find(&Comments).Error
+	err = query.Apply(db.Model(&models.Comment{}).Preload("User").Preload("Photo").Preload("Photo.Renditions", orderRenditions)).Find(&Comments).Error
 
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -77,6 +95,24 @@ func CommentList(c *gin.Context) {
 		return
 	}
 
+	Comments, pagination := listquery.Page(query, Comments, func(comment models.Comment) listquery.Key {
+		return listquery.KeyOf(comment.GormModel)
+	})
+
+	photoIDs := make([]uint, len(Comments))
+	for i := range Comments {
+		photoIDs[i] = Comments[i].PhotoId
+	}
+
+	likeCounts, likedByMe, err := loadLikes(db, uint(userData["id"].(float64)), photoIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to load likes",
+		})
+		return
+	}
+
 	for i := range Comments {
 		photo := make(map[string]interface{})
 		user := make(map[string]interface{})
@@ -89,7 +125,11 @@ func CommentList(c *gin.Context) {
 		photo["title"] = Comments[i].Photo.Title
 		photo["caption"] = Comments[i].Photo.Caption
 		photo["photo_url"] = Comments[i].Photo.PhotoUrl
+		photo["status"] = Comments[i].Photo.Status
+		photo["renditions"] = renditionList(Comments[i].Photo.Renditions)
 		photo["user_id"] = Comments[i].Photo.UserId
+		photo["like_count"] = likeCounts[Comments[i].Photo.ID]
+		photo["liked_by_me"] = likedByMe[Comments[i].Photo.ID]
 
 		data = append(data, gin.H{
 			"id":         Comments[i].ID,
@@ -103,7 +143,10 @@ func CommentList(c *gin.Context) {
 		})
 	}
 
-	c.JSON(http.StatusOK, data)
+	c.JSON(http.StatusOK, gin.H{
+		"data":       data,
+		"pagination": pagination,
+	})
 }
 
 // CommentByID godoc
@@ -116,11 +159,12 @@ func CommentList(c *gin.Context) {
 // @Router       /comments/{commentId} [get]
 func CommentByID(c *gin.Context) {
 	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
 	commentID := c.Param("commentId")
 	var comment models.Comment
 	var data map[string]interface{}
 
-	if err := db.Preload("User").Preload("Photo").First(&comment, commentID).Error; err != nil {
+	if err := db.Preload("User").Preload("Photo").Preload("Photo.Renditions", orderRenditions).First(&comment, commentID).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Bad Request",
 			"message": err.Error(),
@@ -128,6 +172,15 @@ func CommentByID(c *gin.Context) {
 		return
 	}
 
+	likeCounts, likedByMe, err := loadLikes(db, uint(userData["id"].(float64)), []uint{comment.PhotoId})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to load likes",
+		})
+		return
+	}
+
 	user := make(map[string]interface{})
 	photo := make(map[string]interface{})
 
@@ -139,7 +192,11 @@ func CommentByID(c *gin.Context) {
 	photo["title"] = comment.Photo.Title
 	photo["caption"] = comment.Photo.Caption
 	photo["photo_url"] = comment.Photo.PhotoUrl
+	photo["status"] = comment.Photo.Status
+	photo["renditions"] = renditionList(comment.Photo.Renditions)
 	photo["user_id"] = comment.Photo.UserId
+	photo["like_count"] = likeCounts[comment.Photo.ID]
+	photo["liked_by_me"] = likedByMe[comment.Photo.ID]
 
 	data = gin.H{
 		"id":         comment.ID,
@@ -185,7 +242,7 @@ func CommentUpdate(c *gin.Context) {
 	Comment.UserId = uint(userId)
 
 	err := db.Model(&Comment).Where("id = ?", commentId).Updates(models.Comment{Message: Comment.Message}).First(&Comment).Error
-	res := db.Model(&Comment).Preload("Photo").Where("id = ?", commentId).First(&photo).Error
+	res := db.Model(&Comment).Preload("Photo").Preload("Photo.Renditions", orderRenditions).Where("id = ?", commentId).First(&photo).Error
 
 	if res != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -203,6 +260,20 @@ func CommentUpdate(c *gin.Context) {
 		return
 	}
 
+	photoIDs := make([]uint, len(photo))
+	for i := range photo {
+		photoIDs[i] = photo[i].PhotoId
+	}
+
+	likeCounts, likedByMe, err := loadLikes(db, userId, photoIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to load likes",
+		})
+		return
+	}
+
 	for i := range photo {
 		photos := make(map[string]interface{})
 
@@ -210,8 +281,12 @@ func CommentUpdate(c *gin.Context) {
 		photos["title"] = photo[i].Photo.Title
 		photos["caption"] = photo[i].Photo.Caption
 		photos["photo_url"] = photo[i].Photo.PhotoUrl
+		photos["status"] = photo[i].Photo.Status
+		photos["renditions"] = renditionList(photo[i].Photo.Renditions)
 		photos["user_id"] = photo[i].Photo.UserId
 		photos["updated_at"] = photo[i].Photo.UpdatedAt
+		photos["like_count"] = likeCounts[photo[i].Photo.ID]
+		photos["liked_by_me"] = likedByMe[photo[i].Photo.ID]
 
 		data = append(data, photos)
 
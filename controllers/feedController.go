@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"final-project/database"
+	"final-project/listquery"
+	"final-project/models"
+	"net/http"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type photoCommentCount struct {
+	PhotoId uint
+	Count   int64
+}
+
+// Feed godoc
+// @Summary      Get the feed
+// @Description  get photos from the users the current user follows and their own, newest first; pass next_cursor as cursor for the next page
+// @Tags         Photo
+// @Param        limit   query      int  false  "Page size, at most 100"
+// @Param        cursor   query      string  false  "next_cursor or prev_cursor of another page"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /feed [get]
+func Feed(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	userID := uint(userData["id"].(float64))
+	photos := []models.Photo{}
+	data := []interface{}{}
+
+	query, err := listquery.Parse(c, listquery.Options{Table: "photos"})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	// Fan-out on read: every author's newest photos are read on their own
+	// from idx_photos_user_id_created_at_id, at most one page per author, and
+	// only those candidates are merged. A single WHERE user_id IN (...) would
+	// read and sort every photo of every followed author on each page.
+	authors := db.Raw("SELECT ? AS user_id UNION SELECT followee_id FROM follows WHERE follower_id = ?", userID, userID)
+	perAuthor := query.Apply(db.Table("photos").Select("photos.*").Where("photos.user_id = authors.user_id"))
+
+	err = query.Apply(db.
+		Table("(?) AS authors", authors).
+		Joins("CROSS JOIN LATERAL (?) AS photos", perAuthor).
+		Select("photos.*").
+		Preload("Renditions", orderRenditions).
+		Preload("User", func(tx *gorm.DB) *gorm.DB {
+			return tx.Select("id", "username", "profile_image_url", "show_taken_at", "show_location")
+		})).
+		Find(&photos).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	photos, pagination := listquery.Page(query, photos, func(p models.Photo) listquery.Key {
+		return listquery.KeyOf(p.GormModel)
+	})
+
+	commentCounts, err := countComments(db, photos)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to count comments",
+		})
+
+		return
+	}
+
+	photoIDs := make([]uint, len(photos))
+	for i := range photos {
+		photoIDs[i] = photos[i].ID
+	}
+
+	likeCounts, likedByMe, err := loadLikes(db, userID, photoIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to load likes",
+		})
+
+		return
+	}
+
+	for _, photo := range photos {
+		author := gin.H{}
+		if photo.User != nil {
+			author = gin.H{
+				"id":                photo.User.ID,
+				"username":          photo.User.Username,
+				"profile_image_url": photo.User.ProfileImageURL,
+			}
+		}
+
+		data = append(data, gin.H{
+			"id":            photo.ID,
+			"title":         photo.Title,
+			"caption":       photo.Caption,
+			"photo_url":     photo.PhotoUrl,
+			"status":        photo.Status,
+			"renditions":    renditionList(photo.Renditions),
+			"metadata":      photoMetadata(photo, userID),
+			"user_id":       photo.UserId,
+			"created_at":    photo.CreatedAt,
+			"updated_at":    photo.UpdatedAt,
+			"comment_count": commentCounts[photo.ID],
+			"like_count":    likeCounts[photo.ID],
+			"liked_by_me":   likedByMe[photo.ID],
+			"User":          author,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       data,
+		"pagination": pagination,
+	})
+}
+
+// countComments returns the number of comments per photo with one grouped query.
+func countComments(db *gorm.DB, photos []models.Photo) (map[uint]int64, error) {
+	counts := map[uint]int64{}
+	if len(photos) == 0 {
+		return counts, nil
+	}
+
+	ids := make([]uint, len(photos))
+	for i := range photos {
+		ids[i] = photos[i].ID
+	}
+
+	rows := []photoCommentCount{}
+	if err := db.Model(&models.Comment{}).Select("photo_id, count(*) AS count").Where("photo_id IN ?", ids).Group("photo_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.PhotoId] = row.Count
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"final-project/database"
+	"final-project/listquery"
+	"final-project/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+type followedUser struct {
+	ID              uint       `json:"id"`
+	Username        string     `json:"username"`
+	ProfileImageURL string     `json:"profile_image_url"`
+	FollowedAt      *time.Time `json:"followed_at"`
+	FollowId        uint       `json:"-"`
+	FollowUpdatedAt *time.Time `json:"-"`
+}
+
+// Follow godoc
+// @Summary      Follow a user
+// @Description  follow a user by ID
+// @Tags         User
+// @Param        userId   path      int  true  "User ID"
+// @Success      201  {object}  models.Follow
+// @Security    BearerAuth
+// @Router       /users/{userId}/follow [post]
+func UserFollow(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	followerID := uint(userData["id"].(float64))
+
+	followeeID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Invalid user id",
+		})
+
+		return
+	}
+
+	if err := db.Select("id").First(&models.User{}, followeeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "User not found",
+		})
+
+		return
+	}
+
+	follow := models.Follow{FollowerId: followerID, FolloweeId: uint(followeeID)}
+
+	// Duplicate and self follows are rejected by the database constraints.
+	if err := db.Create(&follow).Error; err != nil {
+		if strings.Contains(err.Error(), "idx_follows_follower_followee") {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Conflict",
+				"message": "You already follow this user",
+			})
+
+			return
+		}
+
+		if strings.Contains(err.Error(), "chk_follows_not_self") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Bad Request",
+				"message": "You cannot follow yourself",
+			})
+
+			return
+		}
+
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, follow)
+}
+
+// Unfollow godoc
+// @Summary      Unfollow a user
+// @Description  stop following a user by ID
+// @Tags         User
+// @Param        userId   path      int  true  "User ID"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/{userId}/follow [delete]
+func UserUnfollow(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	followerID := uint(userData["id"].(float64))
+
+	followeeID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Invalid user id",
+		})
+
+		return
+	}
+
+	res := db.Where("follower_id = ? AND followee_id = ?", followerID, followeeID).Delete(&models.Follow{})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to unfollow user",
+		})
+
+		return
+	}
+
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "You do not follow this user",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "You have successfully unfollowed this user",
+	})
+}
+
+// Followers godoc
+// @Summary      List followers
+// @Description  get the users following a user, most recent first
+// @Tags         User
+// @Param        userId   path      int  true  "User ID"
+// @Param        limit   query      int  false  "Page size, at most 100"
+// @Param        cursor   query      string  false  "next_cursor or prev_cursor of another page"
+// @Param        sort   query      string  false  "created_at, updated_at or id, prefixed with - for descending order (default -created_at)"
+// @Param        created_after   query      string  false  "Only items created at or after this RFC 3339 time"
+// @Param        created_before   query      string  false  "Only items created before this RFC 3339 time"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/{userId}/followers [get]
+func UserFollowers(c *gin.Context) {
+	listFollows(c, "followee_id", "follower_id")
+}
+
+// Following godoc
+// @Summary      List followed users
+// @Description  get the users a user follows, most recent first
+// @Tags         User
+// @Param        userId   path      int  true  "User ID"
+// @Param        limit   query      int  false  "Page size, at most 100"
+// @Param        cursor   query      string  false  "next_cursor or prev_cursor of another page"
+// @Param        sort   query      string  false  "created_at, updated_at or id, prefixed with - for descending order (default -created_at)"
+// @Param        created_after   query      string  false  "Only items created at or after this RFC 3339 time"
+// @Param        created_before   query      string  false  "Only items created before this RFC 3339 time"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/{userId}/following [get]
+func UserFollowing(c *gin.Context) {
+	listFollows(c, "follower_id", "followee_id")
+}
+
+// listFollows pages through the follows whose column "by" is the user in the
+// path and returns the users referenced by column "other".
+func listFollows(c *gin.Context, by, other string) {
+	db := database.GetDB()
+	users := []followedUser{}
+
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Invalid user id",
+		})
+
+		return
+	}
+
+	query, err := listquery.Parse(c, listquery.Options{Table: "follows"})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	err = query.Apply(db.Model(&models.Follow{}).
+		Where("follows."+by+" = ?", userID).
+		Select("users.id, users.username, users.profile_image_url, follows.id AS follow_id, follows.created_at AS followed_at, follows.updated_at AS follow_updated_at").
+		Joins("JOIN users ON users.id = follows." + other)).
+		Scan(&users).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	users, pagination := listquery.Page(query, users, func(u followedUser) listquery.Key {
+		return listquery.Key{ID: u.FollowId, CreatedAt: u.FollowedAt, UpdatedAt: u.FollowUpdatedAt}
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       users,
+		"pagination": pagination,
+	})
+}
@@ -0,0 +1,19 @@
+package controllers
+
+import (
+	"final-project/helpers"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS godoc
+// @Summary      JSON Web Key Set
+// @Description  public keys that verify the access tokens issued by this service
+// @Tags         Auth
+// @Success      200  {object}  map[string]interface{}
+// @Router       /.well-known/jwks.json [get]
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, helpers.JWKS())
+}
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"final-project/database"
+	"final-project/listquery"
+	"final-project/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type likingUser struct {
+	ID              uint       `json:"id"`
+	Username        string     `json:"username"`
+	ProfileImageURL string     `json:"profile_image_url"`
+	LikedAt         *time.Time `json:"liked_at"`
+	LikeId          uint       `json:"-"`
+	LikeUpdatedAt   *time.Time `json:"-"`
+}
+
+type photoLikeCount struct {
+	PhotoId uint
+	Count   int64
+}
+
+// Like godoc
+// @Summary      Like a photo
+// @Description  like a photo by ID
+// @Tags         Photo
+// @Param        photoId   path      int  true  "Photo ID"
+// @Success      201  {object}  models.Like
+// @Security    BearerAuth
+// @Router       /photos/{photoId}/likes [post]
+func PhotoLike(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	userID := uint(userData["id"].(float64))
+
+	photoID, err := strconv.Atoi(c.Param("photoId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Invalid photo id",
+		})
+
+		return
+	}
+
+	if err := db.Select("id").First(&models.Photo{}, photoID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "Photo not found",
+		})
+
+		return
+	}
+
+	like := models.Like{UserId: userID, PhotoId: uint(photoID)}
+
+	if err := db.Create(&like).Error; err != nil {
+		if strings.Contains(err.Error(), "idx_likes_user_photo") {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Conflict",
+				"message": "You already like this photo",
+			})
+
+			return
+		}
+
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, like)
+}
+
+// Unlike godoc
+// @Summary      Unlike a photo
+// @Description  remove the current user's like from a photo
+// @Tags         Photo
+// @Param        photoId   path      int  true  "Photo ID"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /photos/{photoId}/likes [delete]
+func PhotoUnlike(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	userID := uint(userData["id"].(float64))
+
+	photoID, err := strconv.Atoi(c.Param("photoId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Invalid photo id",
+		})
+
+		return
+	}
+
+	res := db.Where("user_id = ? AND photo_id = ?", userID, photoID).Delete(&models.Like{})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to unlike photo",
+		})
+
+		return
+	}
+
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "You do not like this photo",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "You have successfully unliked this photo",
+	})
+}
+
+// Likes godoc
+// @Summary      List likes of a photo
+// @Description  get the users who liked a photo, most recent first
+// @Tags         Photo
+// @Param        photoId   path      int  true  "Photo ID"
+// @Param        limit   query      int  false  "Page size, at most 100"
+// @Param        cursor   query      string  false  "next_cursor or prev_cursor of another page"
+// @Param        sort   query      string  false  "created_at, updated_at or id, prefixed with - for descending order (default -created_at)"
+// @Param        created_after   query      string  false  "Only items created at or after this RFC 3339 time"
+// @Param        created_before   query      string  false  "Only items created before this RFC 3339 time"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /photos/{photoId}/likes [get]
+func PhotoLikes(c *gin.Context) {
+	db := database.GetDB()
+	users := []likingUser{}
+
+	photoID, err := strconv.Atoi(c.Param("photoId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Invalid photo id",
+		})
+
+		return
+	}
+
+	query, err := listquery.Parse(c, listquery.Options{Table: "likes"})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	err = query.Apply(db.Model(&models.Like{}).
+		Where("likes.photo_id = ?", photoID).
+		Select("users.id, users.username, users.profile_image_url, likes.id AS like_id, likes.created_at AS liked_at, likes.updated_at AS like_updated_at").
+		Joins("JOIN users ON users.id = likes.user_id")).
+		Scan(&users).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	users, pagination := listquery.Page(query, users, func(u likingUser) listquery.Key {
+		return listquery.Key{ID: u.LikeId, CreatedAt: u.LikedAt, UpdatedAt: u.LikeUpdatedAt}
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       users,
+		"pagination": pagination,
+	})
+}
+
+// loadLikes returns the like count of each photo and whether userID liked it,
+// using one query for each regardless of the number of photos.
+func loadLikes(db *gorm.DB, userID uint, photoIDs []uint) (map[uint]int64, map[uint]bool, error) {
+	counts := map[uint]int64{}
+	likedByMe := map[uint]bool{}
+
+	if len(photoIDs) == 0 {
+		return counts, likedByMe, nil
+	}
+
+	rows := []photoLikeCount{}
+	if err := db.Model(&models.Like{}).Select("photo_id, count(*) AS count").Where("photo_id IN ?", photoIDs).Group("photo_id").Scan(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.PhotoId] = row.Count
+	}
+
+	liked := []uint{}
+	if err := db.Model(&models.Like{}).Where("user_id = ? AND photo_id IN ?", userID, photoIDs).Pluck("photo_id", &liked).Error; err != nil {
+		return nil, nil, err
+	}
+
+	for _, id := range liked {
+		likedByMe[id] = true
+	}
+
+	return counts, likedByMe, nil
+}
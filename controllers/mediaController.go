@@ -0,0 +1,275 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"final-project/exif"
+	"final-project/helpers"
+	"final-project/imaging"
+	"final-project/models"
+	"final-project/phash"
+	"final-project/storage"
+	"io"
+	"log"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// multipartOverhead is how much a multipart request may exceed the file size
+// limit for its boundaries and text fields.
+const multipartOverhead = 1 << 20
+
+var errMalformedImage = errors.New("Image file is malformed")
+
+// Media godoc
+// @Summary      Download an uploaded file
+// @Description  serve a stored photo by its key; keys are content addressed, so responses can be cached forever
+// @Tags         Media
+// @Param        key   path      string  true  "Media key, as in media_key of a photo"
+// @Produce      jpeg,png,gif
+// @Success      200  {file}  file
+// @Router       /media/{key} [get]
+func MediaGet(c *gin.Context) {
+	key := c.Param("key")
+	etag := `"` + key + `"`
+
+	if !storage.ValidKey(key) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "File not found",
+		})
+
+		return
+	}
+
+	object, err := storage.GetStorage().Get(c.Request.Context(), key)
+	if err == storage.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "File not found",
+		})
+
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to read file",
+		})
+
+		return
+	}
+	defer object.Body.Close()
+
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("ETag", etag)
+	c.Header("Content-Type", object.ContentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Security-Policy", "default-src 'none'")
+
+	// ServeContent answers conditional and range requests for seekable files.
+	if body, ok := object.Body.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, key, object.ModTime, body)
+		return
+	}
+
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.DataFromReader(http.StatusOK, object.Size, object.ContentType, object.Body, nil)
+}
+
+// photoUpload is an uploaded photo file that has not been stored yet. Hash
+// is nil for images the standard library cannot decode.
+type photoUpload struct {
+	Data     []byte
+	Metadata exif.Metadata
+	Hash     *int64
+}
+
+// readPhotoFile reads the file uploaded as field, or returns nil when the
+// request carries no such file. Unless UPLOAD_STRIP_METADATA is false, the
+// file is turned upright and stripped of its metadata, GPS position included.
+func readPhotoFile(c *gin.Context, field string) (*photoUpload, error) {
+	header, err := c.FormFile(field)
+	if err == http.ErrMissingFile || err == http.ErrNotMultipart {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if header.Size > storage.MaxUploadSize() {
+		return nil, storage.ErrTooLarge
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, storage.MaxUploadSize()+1))
+	if err != nil {
+		return nil, err
+	}
+
+	// Images without metadata are common; only the fields found are kept.
+	upload := &photoUpload{}
+	upload.Metadata, _ = exif.Parse(data)
+
+	if helpers.GetEnvBool("UPLOAD_STRIP_METADATA", true) {
+		if data, err = exif.Strip(data, upload.Metadata.Orientation); errors.Is(err, imaging.ErrTooManyPixels) {
+			return nil, err
+		} else if err != nil {
+			return nil, errMalformedImage
+		}
+	}
+
+	upload.Data = data
+
+	// Formats the standard library cannot decode, like WebP, go unhashed, but
+	// nothing is stored that the renditions could not be made from.
+	if hash, err := phash.Compute(data); err == nil {
+		value := int64(hash)
+		upload.Hash = &value
+	} else if errors.Is(err, imaging.ErrTooManyPixels) {
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// storePhotoFile stores an upload read by readPhotoFile and returns its key.
+func storePhotoFile(c *gin.Context, upload *photoUpload) (string, error) {
+	key, _, err := storage.Save(c.Request.Context(), storage.GetStorage(), bytes.NewReader(upload.Data))
+
+	return key, err
+}
+
+// applyPhotoMetadata copies the EXIF fields kept for photos. The position is
+// rounded to PHOTO_LOCATION_PRECISION decimals, 1 by default which is about
+// 11 km; a negative precision drops it.
+func applyPhotoMetadata(photo *models.Photo, meta exif.Metadata) {
+	photo.TakenAt = meta.TakenAt
+	photo.CameraModel = meta.Camera()
+	photo.Orientation = meta.Orientation
+	photo.Latitude = nil
+	photo.Longitude = nil
+
+	precision := helpers.GetEnvInt("PHOTO_LOCATION_PRECISION", 1)
+	if precision < 0 || meta.Latitude == nil || meta.Longitude == nil {
+		return
+	}
+
+	scale := math.Pow(10, float64(precision))
+	latitude := math.Round(*meta.Latitude*scale) / scale
+	longitude := math.Round(*meta.Longitude*scale) / scale
+
+	photo.Latitude = &latitude
+	photo.Longitude = &longitude
+}
+
+// photoMetadata is the metadata object of photo payloads. The capture date
+// and position are only shown to others when the owner chose to publish
+// them, so photo.User has to be loaded with show_taken_at and show_location.
+func photoMetadata(photo models.Photo, viewerID uint) gin.H {
+	metadata := gin.H{
+		"camera_model": photo.CameraModel,
+		"orientation":  photo.Orientation,
+		"latitude":     nil,
+		"longitude":    nil,
+		"taken_at":     nil,
+	}
+
+	owner := photo.UserId == viewerID
+	if owner || (photo.User != nil && photo.User.ShowTakenAt) {
+		metadata["taken_at"] = photo.TakenAt
+	}
+
+	if owner || (photo.User != nil && photo.User.ShowLocation) {
+		metadata["latitude"] = photo.Latitude
+		metadata["longitude"] = photo.Longitude
+	}
+
+	return metadata
+}
+
+// abortUpload reports an error of readPhotoFile or storePhotoFile.
+func abortUpload(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+
+	switch {
+	case errors.Is(err, storage.ErrTooLarge) || errors.As(err, &tooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":   "Request Entity Too Large",
+			"message": storage.ErrTooLarge.Error(),
+		})
+	case errors.Is(err, imaging.ErrTooManyPixels):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":   "Request Entity Too Large",
+			"message": err.Error(),
+		})
+	case errors.Is(err, errMalformedImage):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+	case errors.Is(err, storage.ErrUnsupportedType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error":   "Unsupported Media Type",
+			"message": err.Error(),
+		})
+	default:
+		log.Println("Failed to store upload: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to store file",
+		})
+	}
+}
+
+// orderRenditions preloads renditions from the smallest to the largest.
+func orderRenditions(tx *gorm.DB) *gorm.DB {
+	return tx.Order("size")
+}
+
+// renditionList is the renditions array of photo payloads.
+func renditionList(renditions []models.PhotoRendition) []gin.H {
+	list := []gin.H{}
+
+	for _, rendition := range renditions {
+		list = append(list, gin.H{
+			"size":   rendition.Size,
+			"width":  rendition.Width,
+			"height": rendition.Height,
+			"url":    storage.URL(rendition.MediaKey),
+		})
+	}
+
+	return list
+}
+
+// removeUnusedMedia deletes a stored file once no photo or rendition refers to
+// it anymore. Identical uploads share one file, so it may still be in use.
+func removeUnusedMedia(c *gin.Context, db *gorm.DB, key string) {
+	if key == "" {
+		return
+	}
+
+	var photos, renditions int64
+	if err := db.Model(&models.Photo{}).Where("media_key = ?", key).Count(&photos).Error; err != nil || photos > 0 {
+		return
+	}
+
+	if err := db.Model(&models.PhotoRendition{}).Where("media_key = ?", key).Count(&renditions).Error; err != nil || renditions > 0 {
+		return
+	}
+
+	if err := storage.GetStorage().Delete(c.Request.Context(), key); err != nil {
+		log.Println("Failed to delete media: ", err)
+	}
+}
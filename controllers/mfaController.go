@@ -0,0 +1,467 @@
+package controllers
+
+import (
+	"errors"
+	"final-project/database"
+	"final-project/helpers"
+	"final-project/lockout"
+	"final-project/models"
+	"final-project/revocation"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type mfaCodeInput struct {
+	Code         string `json:"code" form:"code"`
+	RecoveryCode string `json:"recovery_code" form:"recovery_code"`
+}
+
+type mfaDisableInput struct {
+	Password     string `json:"password" form:"password"`
+	Code         string `json:"code" form:"code"`
+	RecoveryCode string `json:"recovery_code" form:"recovery_code"`
+}
+
+type mfaLoginInput struct {
+	MfaToken     string `json:"mfa_token" form:"mfa_token"`
+	Code         string `json:"code" form:"code"`
+	RecoveryCode string `json:"recovery_code" form:"recovery_code"`
+}
+
+const recoveryCodeCount = 10
+
+// EnrollTOTP godoc
+// @Summary      Start two-factor enrollment
+// @Description  generate a TOTP secret for the current user; it is only enabled after confirmation
+// @Tags         User
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/me/2fa/enroll [post]
+func UserEnrollTOTP(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	userID := uint(userData["id"].(float64))
+	user := models.User{}
+
+	if err := db.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "User not found",
+		})
+
+		return
+	}
+
+	if user.TotpEnabledAt != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Conflict",
+			"message": "Two-factor authentication is already enabled",
+		})
+
+		return
+	}
+
+	secret, err := helpers.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to generate secret",
+		})
+
+		return
+	}
+
+	if err := db.Model(&user).Update("totp_secret", secret).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to store secret",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      secret,
+		"otpauth_uri": helpers.TOTPURI(helpers.GetEnv("TOTP_ISSUER", "Final Project"), user.Email, secret),
+	})
+}
+
+// ConfirmTOTP godoc
+// @Summary      Confirm two-factor enrollment
+// @Description  enable two-factor authentication with a first code and return one-time recovery codes
+// @Tags         User
+// @Param        code formData string true "TOTP code"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/me/2fa/confirm [post]
+func UserConfirmTOTP(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	contentType := helpers.GetContentType(c)
+	userID := uint(userData["id"].(float64))
+	input := mfaCodeInput{}
+	user := models.User{}
+
+	if contentType == appJSON {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		if err := c.ShouldBind(&input); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if err := db.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "User not found",
+		})
+
+		return
+	}
+
+	if user.TotpEnabledAt != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Conflict",
+			"message": "Two-factor authentication is already enabled",
+		})
+
+		return
+	}
+
+	if user.TotpSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Start the enrollment first",
+		})
+
+		return
+	}
+
+	step, ok := helpers.ValidateTOTP(user.TotpSecret, input.Code, time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Invalid code",
+		})
+
+		return
+	}
+
+	var codes []string
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"totp_enabled_at": time.Now(),
+			"totp_last_step":  step,
+		}).Error; err != nil {
+			return err
+		}
+
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+
+		return err
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to enable two-factor authentication",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication has been enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTOTP godoc
+// @Summary      Disable two-factor authentication
+// @Description  disable two-factor authentication after re-entering the password and a code; wrong answers count as failed logins
+// @Tags         User
+// @Param        password formData string true "User's Password"
+// @Param        code formData string false "TOTP code"
+// @Param        recovery_code formData string false "Recovery code"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/me/2fa/disable [post]
+func UserDisableTOTP(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	contentType := helpers.GetContentType(c)
+	userID := uint(userData["id"].(float64))
+	input := mfaDisableInput{}
+	user := models.User{}
+
+	if contentType == appJSON {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		if err := c.ShouldBind(&input); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if err := db.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "User not found",
+		})
+
+		return
+	}
+
+	if user.TotpEnabledAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Two-factor authentication is not enabled",
+		})
+
+		return
+	}
+
+	// Like a password change, wrong answers count as failed logins.
+	guard := lockout.GetGuard()
+	ip := c.ClientIP()
+
+	if wait, err := guard.Check(user.Email, ip); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to check login attempts",
+		})
+
+		return
+	} else if wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   "Too Many Requests",
+			"message": "Too many failed attempts, please try again later",
+		})
+
+		return
+	}
+
+	if !helpers.CheckPasswordHash([]byte(user.Password), []byte(input.Password)) || !verifySecondFactor(db, &user, input.Code, input.RecoveryCode) {
+		if err := guard.Fail(user.Email, ip); err != nil {
+			log.Println("Failed to record login attempt: ", err)
+		}
+
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Invalid password or code",
+		})
+
+		return
+	}
+
+	if err := guard.Succeed(user.Email); err != nil {
+		log.Println("Failed to reset login attempts: ", err)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"totp_secret":     "",
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to disable two-factor authentication",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication has been disabled",
+	})
+}
+
+// LoginMFA godoc
+// @Summary      Complete a two-factor login
+// @Description  exchange the mfa token returned by the login and a TOTP or recovery code for access tokens
+// @Tags         User
+// @Param        mfa_token formData string true "MFA token from the login"
+// @Param        code formData string false "TOTP code"
+// @Param        recovery_code formData string false "Recovery code"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /users/login/mfa [post]
+func UserLoginMFA(c *gin.Context) {
+	db := database.GetDB()
+	contentType := helpers.GetContentType(c)
+	input := mfaLoginInput{}
+	user := models.User{}
+
+	if contentType == appJSON {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		if err := c.ShouldBind(&input); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	claims, err := helpers.ParseToken(input.MfaToken, helpers.TokenTypeMFA)
+	if err == nil && revocation.IsRevoked(claims) {
+		err = errors.New("token has been revoked")
+	}
+
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	if err := db.First(&user, uint(claims["id"].(float64))).Error; err != nil || user.TotpEnabledAt == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "wrong token",
+		})
+
+		return
+	}
+
+	guard := lockout.GetGuard()
+	ip := c.ClientIP()
+
+	if wait, err := guard.Check(user.Email, ip); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to check login attempts",
+		})
+
+		return
+	} else if wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   "Too Many Requests",
+			"message": "Too many failed login attempts, please try again later",
+		})
+
+		return
+	}
+
+	if !verifySecondFactor(db, &user, input.Code, input.RecoveryCode) {
+		if err := guard.Fail(user.Email, ip); err != nil {
+			log.Println("Failed to record login attempt: ", err)
+		}
+
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Invalid code",
+		})
+
+		return
+	}
+
+	if err := guard.Succeed(user.Email); err != nil {
+		log.Println("Failed to reset login attempts: ", err)
+	}
+
+	// An MFA token completes exactly one login.
+	if err := revocation.RevokeToken(claims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to revoke token",
+		})
+
+		return
+	}
+
+	tokens, err := startSession(c, db, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to issue tokens",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// verifySecondFactor accepts either a TOTP code, which cannot be replayed
+// within its time step, or an unused recovery code, which is consumed.
+func verifySecondFactor(db *gorm.DB, user *models.User, code, recoveryCode string) bool {
+	if code != "" {
+		step, ok := helpers.ValidateTOTP(user.TotpSecret, code, time.Now())
+		if !ok {
+			return false
+		}
+
+		res := db.Model(user).Where("totp_last_step < ?", step).Update("totp_last_step", step)
+
+		return res.Error == nil && res.RowsAffected == 1
+	}
+
+	if recoveryCode != "" {
+		res := db.Model(&models.RecoveryCode{}).
+			Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, helpers.HashToken(helpers.NormalizeRecoveryCode(recoveryCode))).
+			Update("used_at", time.Now())
+
+		return res.Error == nil && res.RowsAffected == 1
+	}
+
+	return false
+}
+
+// replaceRecoveryCodes discards the user's recovery codes and returns a fresh
+// set; only their hashes are stored.
+func replaceRecoveryCodes(db *gorm.DB, userID uint) ([]string, error) {
+	if err := db.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]models.RecoveryCode, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := helpers.GenerateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, code)
+		rows = append(rows, models.RecoveryCode{
+			UserId:   userID,
+			CodeHash: helpers.HashToken(helpers.NormalizeRecoveryCode(code)),
+		})
+	}
+
+	if err := db.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
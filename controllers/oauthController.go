@@ -0,0 +1,440 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"final-project/database"
+	"final-project/helpers"
+	"final-project/models"
+	"final-project/oauth"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errAuthorizationInvalid = errors.New("Invalid or expired authorization state")
+
+// oauthStateCookie binds a state to the browser that asked for it, so an
+// authorization URL cannot be completed from another browser.
+const oauthStateCookie = "oauth_state"
+
+// OAuthProviders godoc
+// @Summary      List identity providers
+// @Description  get the external identity providers users can sign in with
+// @Tags         User
+// @Success      200  {object}  []string
+// @Router       /users/oauth/providers [get]
+func OAuthProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, oauth.ProviderNames())
+}
+
+// OAuthAuthorize godoc
+// @Summary      Start signing in with an identity provider
+// @Description  get the provider URL to send the user to; the provider redirects back to the callback with a code. The state is also set as a cookie the callback checks.
+// @Tags         User
+// @Param        provider   path      string  true  "Provider name"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /users/oauth/{provider}/authorize [get]
+func OAuthAuthorize(c *gin.Context) {
+	startAuthorization(c, nil)
+}
+
+// OAuthCallback godoc
+// @Summary      Finish signing in with an identity provider
+// @Description  exchange the provider's authorization code for tokens; the identity must already be linked or carry the verified email of an existing user whose own email is verified. The state cookie set by authorize must be sent along; a state started by linking links the identity to the user who started it.
+// @Tags         User
+// @Param        provider   path      string  true  "Provider name"
+// @Param        code   query      string  true  "Authorization code"
+// @Param        state   query      string  true  "State returned by authorize"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /users/oauth/{provider}/callback [get]
+func OAuthCallback(c *gin.Context) {
+	db := database.GetDB()
+	provider, ok := oauth.GetProvider(c.Param("provider"))
+
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "Unknown identity provider",
+		})
+
+		return
+	}
+
+	if reason := c.Query("error"); reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "The identity provider refused the sign in: " + reason,
+		})
+
+		return
+	}
+
+	if c.Query("code") == "" || c.Query("state") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Code and state are required",
+		})
+
+		return
+	}
+
+	cookie, _ := c.Cookie(oauthStateCookie)
+	clearStateCookie(c)
+
+	if subtle.ConstantTimeCompare([]byte(cookie), []byte(c.Query("state"))) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": errAuthorizationInvalid.Error(),
+		})
+
+		return
+	}
+
+	request := models.AuthorizationRequest{}
+
+	// Every state can be redeemed once, so a replayed callback fails.
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("state_hash = ? AND provider = ?", helpers.HashToken(c.Query("state")), provider.Name).First(&request).Error; err != nil {
+			return errAuthorizationInvalid
+		}
+
+		if request.UsedAt != nil || time.Now().After(request.ExpiresAt) {
+			return errAuthorizationInvalid
+		}
+
+		res := tx.Model(&models.AuthorizationRequest{}).Where("id = ? AND used_at IS NULL", request.ID).Update("used_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			return errAuthorizationInvalid
+		}
+
+		return nil
+	})
+
+	if err == errAuthorizationInvalid {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to check authorization state",
+		})
+
+		return
+	}
+
+	accessToken, err := provider.Exchange(c.Query("code"), request.CodeVerifier)
+	if err != nil {
+		log.Println("OAuth code exchange failed: ", err)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Bad Gateway",
+			"message": "Failed to exchange the authorization code",
+		})
+
+		return
+	}
+
+	identity, err := provider.UserInfo(accessToken)
+	if err != nil {
+		log.Println("OAuth userinfo request failed: ", err)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Bad Gateway",
+			"message": "Failed to fetch the user's identity",
+		})
+
+		return
+	}
+
+	if request.UserId != nil {
+		linkIdentity(c, *request.UserId, provider.Name, identity)
+		return
+	}
+
+	user := models.User{}
+	linked := models.UserIdentity{}
+
+	if err := db.Where("provider = ? AND subject = ?", provider.Name, identity.Subject).First(&linked).Error; err == nil {
+		if err := db.First(&user, linked.UserId).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Not Found",
+				"message": "User not found",
+			})
+
+			return
+		}
+	} else {
+		// An unlinked identity is only trusted when the provider vouches for the email.
+		if identity.Email == "" || !identity.EmailVerified {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "Forbidden",
+				"message": "The identity provider did not confirm a verified email",
+			})
+
+			return
+		}
+
+		if err := db.Where("email = ?", identity.Email).First(&user).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Not Found",
+				"message": "No account is registered with this email",
+			})
+
+			return
+		}
+
+		// Whoever registered an unverified account may not own the address,
+		// so it is never taken over by signing in with the provider.
+		if user.VerifiedAt == nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "Forbidden",
+				"message": "Sign in with your password and link this identity from your account first",
+			})
+
+			return
+		}
+
+		if err := db.Create(&models.UserIdentity{UserId: user.ID, Provider: provider.Name, Subject: identity.Subject, Email: identity.Email}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Internal Server Error",
+				"message": "Failed to link identity",
+			})
+
+			return
+		}
+	}
+
+	// Two-factor authentication still applies, as with a password login.
+	if user.TotpEnabledAt != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required": true,
+			"mfa_token":    helpers.GenerateMFAToken(user.ID),
+			"expires_in":   int(helpers.MFATokenTTL().Seconds()),
+		})
+
+		return
+	}
+
+	tokens, err := startSession(c, db, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to issue tokens",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// ListIdentities godoc
+// @Summary      List linked identities
+// @Description  get the external identities linked to the current user
+// @Tags         User
+// @Success      200  {object}  []models.UserIdentity
+// @Security    BearerAuth
+// @Router       /users/me/identities [get]
+func IdentityList(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	userID := uint(userData["id"].(float64))
+	identities := []models.UserIdentity{}
+
+	if err := db.Where("user_id = ?", userID).Order("created_at desc").Find(&identities).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, identities)
+}
+
+// LinkIdentity godoc
+// @Summary      Link an identity
+// @Description  get the provider URL to link another external identity to the current user; the callback has to be completed in the same browser, which carries the state cookie
+// @Tags         User
+// @Param        provider   path      string  true  "Provider name"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/me/identities/{provider} [post]
+func IdentityLink(c *gin.Context) {
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	userID := uint(userData["id"].(float64))
+
+	startAuthorization(c, &userID)
+}
+
+// UnlinkIdentity godoc
+// @Summary      Unlink an identity
+// @Description  remove one of the external identities linked to the current user
+// @Tags         User
+// @Param        identityId   path      int  true  "Identity ID"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/me/identities/{identityId} [delete]
+func IdentityUnlink(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	userID := uint(userData["id"].(float64))
+
+	identityID, err := strconv.Atoi(c.Param("identityId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Invalid identity id",
+		})
+
+		return
+	}
+
+	res := db.Where("id = ? AND user_id = ?", identityID, userID).Delete(&models.UserIdentity{})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to unlink identity",
+		})
+
+		return
+	}
+
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "Identity not found",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Your identity has been successfully unlinked",
+	})
+}
+
+// startAuthorization stores a fresh state and PKCE verifier, binds the state
+// to the browser with a cookie and returns the provider URL. userID is set
+// when the identity should be linked to a signed in user instead of signing in.
+func startAuthorization(c *gin.Context, userID *uint) {
+	db := database.GetDB()
+	provider, ok := oauth.GetProvider(c.Param("provider"))
+
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "Unknown identity provider",
+		})
+
+		return
+	}
+
+	state, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to generate state",
+		})
+
+		return
+	}
+
+	verifier, err := oauth.NewCodeVerifier()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to generate code verifier",
+		})
+
+		return
+	}
+
+	ttl := helpers.GetEnvDuration("OAUTH_STATE_TTL", 10*time.Minute)
+	request := models.AuthorizationRequest{
+		Provider:     provider.Name,
+		StateHash:    helpers.HashToken(state),
+		CodeVerifier: verifier,
+		UserId:       userID,
+		ExpiresAt:    time.Now().Add(ttl),
+	}
+
+	if err := db.Create(&request).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to store authorization state",
+		})
+
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, state, int(ttl.Seconds()), oauthCookiePath, "", secureCookies(), true)
+
+	c.JSON(http.StatusOK, gin.H{
+		"authorization_url": provider.AuthCodeURL(state, oauth.CodeChallenge(verifier)),
+		"state":             state,
+		"expires_in":        int(ttl.Seconds()),
+	})
+}
+
+// oauthCookiePath covers the callbacks of every provider.
+const oauthCookiePath = "/users/oauth/"
+
+func clearStateCookie(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, "", -1, oauthCookiePath, "", secureCookies(), true)
+}
+
+// secureCookies keeps cookies off plain HTTP once the app is served over HTTPS.
+func secureCookies() bool {
+	return strings.HasPrefix(helpers.GetEnv("APP_URL", "http://localhost:8080"), "https://")
+}
+
+func linkIdentity(c *gin.Context, userID uint, provider string, identity oauth.Identity) {
+	db := database.GetDB()
+	existing := models.UserIdentity{}
+
+	if err := db.Where("provider = ? AND subject = ?", provider, identity.Subject).First(&existing).Error; err == nil {
+		if existing.UserId != userID {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Conflict",
+				"message": "This identity is already linked to another account",
+			})
+
+			return
+		}
+
+		c.JSON(http.StatusOK, existing)
+
+		return
+	}
+
+	linked := models.UserIdentity{UserId: userID, Provider: provider, Subject: identity.Subject, Email: identity.Email}
+	if err := db.Create(&linked).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to link identity",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, linked)
+}
@@ -0,0 +1,290 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"final-project/database"
+	"final-project/models"
+	"final-project/oauth"
+	"final-project/oauth/oauthtest"
+	"final-project/router"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	dbOnce sync.Once
+	dbErr  error
+)
+
+// TestMain runs the tests in an empty directory: token signing loads .env
+// from the working directory.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "controllers-test")
+	if err == nil {
+		err = os.WriteFile(dir+"/.env", nil, 0o600)
+	}
+
+	if err == nil {
+		err = os.Chdir(dir)
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	os.Setenv("JWT_SECRET", "test-secret")
+	gin.SetMode(gin.TestMode)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// setup connects to TEST_DATABASE_URL and points the "mock" provider at a
+// fresh mock OIDC provider.
+func setup(t *testing.T) (*gin.Engine, *oauthtest.Server) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	dbOnce.Do(func() { dbErr = database.Connect(dsn) })
+	if dbErr != nil {
+		t.Fatalf("connect: %s", dbErr)
+	}
+
+	server := oauthtest.NewServer()
+	t.Cleanup(server.Close)
+	oauth.SetProviders(server.Provider("mock"))
+
+	return router.StartApp(), server
+}
+
+func createUser(t *testing.T, verified bool) models.User {
+	t.Helper()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	user := models.User{
+		Username:        "oauth" + suffix,
+		Email:           "oauth" + suffix + "@example.com",
+		Password:        "correct horse battery",
+		ProfileImageURL: "https://example.com/avatar.png",
+		Age:             20,
+	}
+
+	if verified {
+		now := time.Now()
+		user.VerifiedAt = &now
+	}
+
+	if err := database.GetDB().Create(&user).Error; err != nil {
+		t.Fatalf("create user: %s", err)
+	}
+
+	t.Cleanup(func() { database.GetDB().Delete(&models.User{}, user.ID) })
+
+	return user
+}
+
+type call struct {
+	method  string
+	path    string
+	token   string
+	cookies []*http.Cookie
+	body    interface{}
+}
+
+func do(t *testing.T, r *gin.Engine, req call) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	if req.body != nil {
+		json.NewEncoder(body).Encode(req.body)
+	}
+
+	httpReq := httptest.NewRequest(req.method, req.path, body)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	if req.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+req.token)
+	}
+
+	for _, cookie := range req.cookies {
+		httpReq.AddCookie(cookie)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httpReq)
+
+	payload := map[string]interface{}{}
+	json.Unmarshal(rec.Body.Bytes(), &payload)
+
+	return rec, payload
+}
+
+func login(t *testing.T, r *gin.Engine, user models.User) string {
+	t.Helper()
+
+	rec, payload := do(t, r, call{method: http.MethodPost, path: "/users/login", body: map[string]string{
+		"email":    user.Email,
+		"password": "correct horse battery",
+	}})
+
+	token, _ := payload["token"].(string)
+	if rec.Code != http.StatusOK || token == "" {
+		t.Fatalf("login: %d %s", rec.Code, rec.Body)
+	}
+
+	return token
+}
+
+// signIn starts an authorization at path and signs in at the mock provider
+// as identity. It returns the callback URL and the state cookie.
+func signIn(t *testing.T, r *gin.Engine, server *oauthtest.Server, start call, identity oauthtest.Identity) (string, []*http.Cookie) {
+	t.Helper()
+
+	rec, payload := do(t, r, start)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("authorize: %d %s", rec.Code, rec.Body)
+	}
+
+	authURL, _ := payload["authorization_url"].(string)
+	code, state, err := server.Authorize(authURL, identity)
+	if err != nil {
+		t.Fatalf("mock provider: %s", err)
+	}
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) == 0 || cookies[0].Value != state || !cookies[0].HttpOnly {
+		t.Fatalf("authorize did not set an HttpOnly state cookie: %v", cookies)
+	}
+
+	query := url.Values{"code": {code}, "state": {state}}
+
+	return "/users/oauth/mock/callback?" + query.Encode(), cookies
+}
+
+func subject() string {
+	return "subject-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+}
+
+func TestOAuthSignInWithVerifiedEmail(t *testing.T) {
+	r, server := setup(t)
+	user := createUser(t, true)
+	identity := oauthtest.Identity{Subject: subject(), Email: user.Email, EmailVerified: true}
+
+	callback, cookies := signIn(t, r, server, call{method: http.MethodGet, path: "/users/oauth/mock/authorize"}, identity)
+
+	rec, payload := do(t, r, call{method: http.MethodGet, path: callback, cookies: cookies})
+	token, _ := payload["token"].(string)
+	if rec.Code != http.StatusOK || token == "" {
+		t.Fatalf("callback: %d %s", rec.Code, rec.Body)
+	}
+
+	if rec, _ := do(t, r, call{method: http.MethodGet, path: "/users/me", token: token}); rec.Code != http.StatusOK {
+		t.Fatalf("token from the callback was rejected: %d %s", rec.Code, rec.Body)
+	}
+
+	var linked int64
+	database.GetDB().Model(&models.UserIdentity{}).Where("user_id = ? AND provider = ? AND subject = ?", user.ID, "mock", identity.Subject).Count(&linked)
+	if linked != 1 {
+		t.Fatalf("identity was not linked to the user")
+	}
+
+	// Replaying the callback fails even with the right cookie.
+	if rec, _ := do(t, r, call{method: http.MethodGet, path: callback, cookies: cookies}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("replayed callback: %d %s", rec.Code, rec.Body)
+	}
+
+	// The now linked identity signs in without the email.
+	callback, cookies = signIn(t, r, server, call{method: http.MethodGet, path: "/users/oauth/mock/authorize"}, oauthtest.Identity{Subject: identity.Subject})
+	if rec, _ := do(t, r, call{method: http.MethodGet, path: callback, cookies: cookies}); rec.Code != http.StatusOK {
+		t.Fatalf("sign in with a linked identity: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestOAuthCallbackRequiresStateCookie(t *testing.T) {
+	r, server := setup(t)
+	user := createUser(t, true)
+	identity := oauthtest.Identity{Subject: subject(), Email: user.Email, EmailVerified: true}
+
+	callback, cookies := signIn(t, r, server, call{method: http.MethodGet, path: "/users/oauth/mock/authorize"}, identity)
+
+	if rec, _ := do(t, r, call{method: http.MethodGet, path: callback}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("callback without the state cookie: %d %s", rec.Code, rec.Body)
+	}
+
+	forged := []*http.Cookie{{Name: cookies[0].Name, Value: "forged"}}
+	if rec, _ := do(t, r, call{method: http.MethodGet, path: callback, cookies: forged}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("callback with another state cookie: %d %s", rec.Code, rec.Body)
+	}
+
+	// Rejected callbacks do not use up the state.
+	if rec, _ := do(t, r, call{method: http.MethodGet, path: callback, cookies: cookies}); rec.Code != http.StatusOK {
+		t.Fatalf("callback with the state cookie: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestOAuthRefusesUnverifiedAccount(t *testing.T) {
+	r, server := setup(t)
+	user := createUser(t, false)
+	identity := oauthtest.Identity{Subject: subject(), Email: user.Email, EmailVerified: true}
+
+	callback, cookies := signIn(t, r, server, call{method: http.MethodGet, path: "/users/oauth/mock/authorize"}, identity)
+
+	if rec, _ := do(t, r, call{method: http.MethodGet, path: callback, cookies: cookies}); rec.Code != http.StatusForbidden {
+		t.Fatalf("callback for an unverified account: %d %s", rec.Code, rec.Body)
+	}
+
+	stored := models.User{}
+	database.GetDB().First(&stored, user.ID)
+	if stored.VerifiedAt != nil {
+		t.Fatal("the sign in verified the account")
+	}
+
+	var linked int64
+	database.GetDB().Model(&models.UserIdentity{}).Where("user_id = ?", user.ID).Count(&linked)
+	if linked != 0 {
+		t.Fatal("the identity was linked to an unverified account")
+	}
+}
+
+func TestOAuthLinkIdentity(t *testing.T) {
+	r, server := setup(t)
+	owner := createUser(t, true)
+	other := createUser(t, true)
+	identity := oauthtest.Identity{Subject: subject()}
+
+	callback, cookies := signIn(t, r, server, call{method: http.MethodPost, path: "/users/me/identities/mock", token: login(t, r, owner)}, identity)
+	_, otherCookies := signIn(t, r, server, call{method: http.MethodPost, path: "/users/me/identities/mock", token: login(t, r, other)}, oauthtest.Identity{Subject: subject()})
+
+	// The provider redirects the browser back without an access token; the
+	// state cookie alone ties the callback to the user who started the link.
+	if rec, _ := do(t, r, call{method: http.MethodGet, path: callback}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("link callback without the state cookie: %d %s", rec.Code, rec.Body)
+	}
+
+	if rec, _ := do(t, r, call{method: http.MethodGet, path: callback, cookies: otherCookies}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("link callback from another user's browser: %d %s", rec.Code, rec.Body)
+	}
+
+	rec, payload := do(t, r, call{method: http.MethodGet, path: callback, cookies: cookies})
+	if rec.Code != http.StatusCreated || payload["user_id"] != float64(owner.ID) {
+		t.Fatalf("link callback: %d %s", rec.Code, rec.Body)
+	}
+
+	if rec, _ := do(t, r, call{method: http.MethodGet, path: callback, cookies: cookies}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("replayed link callback: %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,387 @@
+package controllers
+
+import (
+	"errors"
+	"final-project/database"
+	"final-project/helpers"
+	"final-project/lockout"
+	"final-project/mailer"
+	"final-project/models"
+	"final-project/revocation"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type forgotPasswordInput struct {
+	Email string `json:"email" form:"email"`
+}
+
+type resetPasswordInput struct {
+	Token    string `json:"token" form:"token"`
+	Password string `json:"password" form:"password"`
+}
+
+type changePasswordInput struct {
+	CurrentPassword string `json:"current_password" form:"current_password"`
+	NewPassword     string `json:"new_password" form:"new_password"`
+}
+
+var errResetTokenInvalid = errors.New("Invalid or expired reset token")
+
+// errPasswordPolicy marks a rejected new password so it can be reported as a bad request.
+type errPasswordPolicy struct {
+	error
+}
+
+// ForgotPassword godoc
+// @Summary      Request a password reset
+// @Description  email a single-use password reset link to the user
+// @Tags         User
+// @Param        email formData string true "User's Email"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /users/password/forgot [post]
+func UserForgotPassword(c *gin.Context) {
+	db := database.GetDB()
+	contentType := helpers.GetContentType(c)
+	input := forgotPasswordInput{}
+
+	if contentType == appJSON {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		if err := c.ShouldBind(&input); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if input.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Email is required",
+		})
+
+		return
+	}
+
+	// The response is the same whether or not the email exists so the endpoint cannot be used to enumerate accounts.
+	response := gin.H{
+		"message": "If the email is registered, a password reset link has been sent",
+	}
+
+	user := models.User{}
+	if err := db.Where("email = ?", input.Email).First(&user).Error; err != nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	token, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to generate reset token",
+		})
+
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Requesting a new link invalidates the previous ones.
+		if err := tx.Model(&models.PasswordResetToken{}).Where("user_id = ? AND used_at IS NULL", user.ID).Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.PasswordResetToken{
+			UserId:    user.ID,
+			TokenHash: helpers.HashToken(token),
+			ExpiresAt: time.Now().Add(helpers.GetEnvDuration("PASSWORD_RESET_TTL", time.Hour)),
+		}).Error
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to store reset token",
+		})
+
+		return
+	}
+
+	link := fmt.Sprintf("%s?token=%s", helpers.GetEnv("PASSWORD_RESET_URL", "http://localhost:8080/reset-password"), token)
+	err = mailer.GetSender().Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body:    fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It can only be used once.\n\n%s\n\nIf you did not request this, you can ignore this email.", user.Username, link),
+	})
+
+	if err != nil {
+		log.Println("Failed to send password reset email: ", err)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetPassword godoc
+// @Summary      Reset a password
+// @Description  set a new password using a token from the password reset email
+// @Tags         User
+// @Param        token formData string true "Reset Token"
+// @Param        password formData string true "New Password"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /users/password/reset [post]
+func UserResetPassword(c *gin.Context) {
+	db := database.GetDB()
+	contentType := helpers.GetContentType(c)
+	input := resetPasswordInput{}
+
+	if contentType == appJSON {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		if err := c.ShouldBind(&input); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if input.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Token is required",
+		})
+
+		return
+	}
+
+	user := models.User{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		resetToken := models.PasswordResetToken{}
+
+		if err := tx.Where("token_hash = ?", helpers.HashToken(input.Token)).First(&resetToken).Error; err != nil {
+			return errResetTokenInvalid
+		}
+
+		if resetToken.UsedAt != nil || time.Now().After(resetToken.ExpiresAt) {
+			return errResetTokenInvalid
+		}
+
+		res := tx.Model(&models.PasswordResetToken{}).Where("id = ? AND used_at IS NULL", resetToken.ID).Update("used_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			return errResetTokenInvalid
+		}
+
+		if err := tx.First(&user, resetToken.UserId).Error; err != nil {
+			return errResetTokenInvalid
+		}
+
+		if err := helpers.ValidatePassword(input.Password, user.Username, user.Email); err != nil {
+			return errPasswordPolicy{err}
+		}
+
+		hash, err := helpers.HashPassword(input.Password)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&user).Update("password", hash).Error
+	})
+
+	if policyErr, ok := err.(errPasswordPolicy); ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": policyErr.Error(),
+		})
+
+		return
+	}
+
+	if err == errResetTokenInvalid {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to reset password",
+		})
+
+		return
+	}
+
+	if err := revocation.RevokeUser(db, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to revoke user tokens",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Your password has been successfully reset",
+	})
+}
+
+// ChangePassword godoc
+// @Summary      Change the password
+// @Description  change the current user's password; every other session is signed out, personal access tokens are revoked and a fresh token pair is returned
+// @Tags         User
+// @Param        current_password formData string true "Current Password"
+// @Param        new_password formData string true "New Password"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/me/password [put]
+func UserChangePassword(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	contentType := helpers.GetContentType(c)
+	userID := uint(userData["id"].(float64))
+	input := changePasswordInput{}
+	user := models.User{}
+
+	if contentType == appJSON {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		if err := c.ShouldBind(&input); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if err := db.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "User not found",
+		})
+
+		return
+	}
+
+	// Wrong current passwords count as failed logins, so a stolen access
+	// token cannot be used to guess the password at full speed.
+	guard := lockout.GetGuard()
+	ip := c.ClientIP()
+
+	if wait, err := guard.Check(user.Email, ip); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to check login attempts",
+		})
+
+		return
+	} else if wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   "Too Many Requests",
+			"message": "Too many failed password attempts, please try again later",
+		})
+
+		return
+	}
+
+	if !helpers.CheckPasswordHash([]byte(user.Password), []byte(input.CurrentPassword)) {
+		if err := guard.Fail(user.Email, ip); err != nil {
+			log.Println("Failed to record login attempt: ", err)
+		}
+
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Current password is incorrect",
+		})
+
+		return
+	}
+
+	if err := guard.Succeed(user.Email); err != nil {
+		log.Println("Failed to reset login attempts: ", err)
+	}
+
+	if input.NewPassword == input.CurrentPassword {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "New password must be different from the current password",
+		})
+
+		return
+	}
+
+	if err := helpers.ValidatePassword(input.NewPassword, user.Username, user.Email); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	hash, err := helpers.HashPassword(input.NewPassword)
+	if err == nil {
+		err = db.Model(&user).Update("password", hash).Error
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to change password",
+		})
+
+		return
+	}
+
+	// Sign out everywhere, including this token, then hand the caller a fresh pair.
+	if err := revocation.RevokeToken(userData); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to revoke token",
+		})
+
+		return
+	}
+
+	if err := revocation.RevokeUser(db, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to revoke user tokens",
+		})
+
+		return
+	}
+
+	tokens, err := startSession(c, db, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to issue tokens",
+		})
+
+		return
+	}
+
+	tokens["message"] = "Your password has been successfully changed"
+	c.JSON(http.StatusOK, tokens)
+}
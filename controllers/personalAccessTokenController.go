@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"final-project/database"
+	"final-project/helpers"
+	"final-project/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+type personalAccessTokenInput struct {
+	Name          string   `json:"name" form:"name"`
+	Scopes        []string `json:"scopes" form:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days" form:"expires_in_days"`
+}
+
+// CreateToken godoc
+// @Summary      Create a personal access token
+// @Description  create a scoped token for scripts and CI; the token is only shown once
+// @Tags         User
+// @Param        name formData string true "Token name"
+// @Param        scopes formData []string true "Scopes such as photos:read or comments:write" collectionFormat(multi)
+// @Param        expires_in_days formData int false "Days until the token expires, 0 for never"
+// @Success      201  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/me/tokens [post]
+func PersonalAccessTokenCreate(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	contentType := helpers.GetContentType(c)
+	userID := uint(userData["id"].(float64))
+	input := personalAccessTokenInput{}
+
+	if contentType == appJSON {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		if err := c.ShouldBind(&input); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if strings.TrimSpace(input.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Name is required",
+		})
+
+		return
+	}
+
+	if len(input.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "At least one scope is required",
+		})
+
+		return
+	}
+
+	for _, scope := range input.Scopes {
+		if !helpers.ValidScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Bad Request",
+				"message": "Invalid scope " + scope,
+			})
+
+			return
+		}
+	}
+
+	if input.ExpiresInDays < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Expiry must not be negative",
+		})
+
+		return
+	}
+
+	secret, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to generate token",
+		})
+
+		return
+	}
+
+	token := helpers.PersonalAccessTokenPrefix + secret
+	pat := models.PersonalAccessToken{
+		UserId:    userID,
+		Name:      strings.TrimSpace(input.Name),
+		Prefix:    token[:len(helpers.PersonalAccessTokenPrefix)+6],
+		TokenHash: helpers.HashToken(token),
+		Scopes:    strings.Join(input.Scopes, " "),
+	}
+
+	if input.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, input.ExpiresInDays)
+		pat.ExpiresAt = &expiresAt
+	}
+
+	if err := db.Create(&pat).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	data := personalAccessTokenData(pat)
+	data["token"] = token
+
+	c.JSON(http.StatusCreated, data)
+}
+
+// ListTokens godoc
+// @Summary      List personal access tokens
+// @Description  get the current user's personal access tokens without their secrets
+// @Tags         User
+// @Success      200  {object}  []models.PersonalAccessToken
+// @Security    BearerAuth
+// @Router       /users/me/tokens [get]
+func PersonalAccessTokenList(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	userID := uint(userData["id"].(float64))
+	tokens := []models.PersonalAccessToken{}
+	data := []interface{}{}
+
+	if err := db.Where("user_id = ?", userID).Order("created_at desc").Find(&tokens).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	for i := range tokens {
+		data = append(data, personalAccessTokenData(tokens[i]))
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+// RevokeToken godoc
+// @Summary      Revoke a personal access token
+// @Description  revoke one of the current user's personal access tokens
+// @Tags         User
+// @Param        tokenId   path      int  true  "Token ID"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/me/tokens/{tokenId} [delete]
+func PersonalAccessTokenRevoke(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	userID := uint(userData["id"].(float64))
+
+	tokenID, err := strconv.Atoi(c.Param("tokenId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Invalid token id",
+		})
+
+		return
+	}
+
+	res := db.Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", tokenID, userID).
+		Update("revoked_at", time.Now())
+
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to revoke token",
+		})
+
+		return
+	}
+
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "Token not found",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Your token has been successfully revoked",
+	})
+}
+
+func personalAccessTokenData(pat models.PersonalAccessToken) gin.H {
+	return gin.H{
+		"id":           pat.ID,
+		"name":         pat.Name,
+		"prefix":       pat.Prefix,
+		"scopes":       pat.ScopeList(),
+		"expires_at":   pat.ExpiresAt,
+		"last_used_at": pat.LastUsedAt,
+		"revoked_at":   pat.RevokedAt,
+		"created_at":   pat.CreatedAt,
+	}
+}
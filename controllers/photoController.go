@@ -2,8 +2,12 @@ package controllers
 
 import (
 	"final-project/database"
+	"final-project/exif"
 	"final-project/helpers"
+	"final-project/listquery"
 	"final-project/models"
+	"final-project/renditions"
+	"final-project/storage"
 	"net/http"
 	"strconv"
 
@@ -22,8 +26,11 @@ type User struct {
 // @Tags         Photo
 // @Param        title formData string true "Photo's Title"
 // @Param        caption formData string true "Photo's Caption"
-// @Param        photo_url formData string true "Photo's Photo URL"
+// @Param        photo_url formData string false "Photo's Photo URL, required without a photo file"
+// @Param        photo formData file false "JPEG, PNG, GIF or WebP image to store instead of linking photo_url"
+// @Accept       json,mpfd
 // @Success      201  {object}  models.Photo
+// @Failure      409  {object}  map[string]interface{}  "A near-duplicate was found and DUPLICATE_PHOTO_POLICY is reject"
 // @Security    BearerAuth
 // @Router       /photos        [post]
 func PhotoCreate(c *gin.Context) {
@@ -34,6 +41,8 @@ func PhotoCreate(c *gin.Context) {
 	Photo := models.Photo{}
 	userID := uint(userData["id"].(float64))
 
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, storage.MaxUploadSize()+multipartOverhead)
+
 	if contentType == appJSON {
 		c.ShouldBindJSON(&Photo)
 	} else {
@@ -41,10 +50,58 @@ func PhotoCreate(c *gin.Context) {
 	}
 
 	Photo.UserId = userID
+	Photo.MediaKey = ""
+	Photo.Status = models.PhotoStatusReady
+
+	upload, err := readPhotoFile(c, "photo")
+	if err != nil {
+		abortUpload(c, err)
+		return
+	}
+
+	meta := exif.Metadata{Orientation: 1}
+	duplicates := []gin.H{}
+
+	if upload != nil {
+		duplicates, err = findDuplicates(db, userID, upload.Hash)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Internal Server Error",
+				"message": "Failed to look for duplicates",
+			})
+			return
+		}
+
+		if len(duplicates) > 0 && helpers.GetEnv("DUPLICATE_PHOTO_POLICY", "warn") == "reject" {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":      "Conflict",
+				"message":    "You already posted a photo that looks the same",
+				"duplicates": duplicates,
+			})
+			return
+		}
+
+		key, err := storePhotoFile(c, upload)
+		if err != nil {
+			abortUpload(c, err)
+			return
+		}
+
+		meta = upload.Metadata
+		Photo.MediaKey = key
+		Photo.PhotoUrl = storage.URL(key)
+		Photo.PerceptualHash = upload.Hash
+		Photo.Status = models.PhotoStatusProcessing
+	}
+
+	applyPhotoMetadata(&Photo, meta)
 
-	err := db.Create(&Photo).Error
+	err = db.Create(&Photo).Error
 
 	if err != nil {
+		// The file was stored before validation; keep no file without a photo.
+		removeUnusedMedia(c, db, Photo.MediaKey)
+
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Bad Request",
 			"message": err.Error(),
@@ -52,30 +109,61 @@ func PhotoCreate(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	if Photo.Status == models.PhotoStatusProcessing {
+		renditions.Enqueue(Photo.ID)
+	}
+
+	response := gin.H{
 		"id":         Photo.ID,
 		"title":      Photo.Title,
 		"caption":    Photo.Caption,
 		"photo_url":  Photo.PhotoUrl,
+		"media_key":  Photo.MediaKey,
+		"status":     Photo.Status,
+		"renditions": []gin.H{},
+		"metadata":   photoMetadata(Photo, userID),
 		"user_id":    Photo.UserId,
 		"created_at": Photo.CreatedAt,
-	})
+		"duplicates": duplicates,
+	}
+
+	if len(duplicates) > 0 {
+		response["warning"] = "You already posted a photo that looks the same"
+	}
+
+	c.JSON(http.StatusCreated, response)
 }
 
 // Fetch godoc
 // @Summary      Fetch photos
-// @Description  get photos
+// @Description  get photos one page at a time
 // @Tags         Photo
-// @Success      200	{object}	[]models.Photo
+// @Param        limit   query      int  false  "Page size, at most 100"
+// @Param        cursor   query      string  false  "next_cursor or prev_cursor of another page"
+// @Param        sort   query      string  false  "created_at, updated_at or id, prefixed with - for descending order (default -created_at)"
+// @Param        user_id   query      int  false  "Only items with this user id"
+// @Param        created_after   query      string  false  "Only items created at or after this RFC 3339 time"
+// @Param        created_before   query      string  false  "Only items created before this RFC 3339 time"
+// @Success      200	{object}	map[string]interface{}
 // @Security    BearerAuth
 // @Router       /photos        [get]
 func PhotoGetAll(c *gin.Context) {
 	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
 	var Photos []models.Photo
 
-	var data []interface{}
+	data := []interface{}{}
 
-	err := db.Preload("User").Find(&Photos).Error
+	query, err := listquery.Parse(c, listquery.Options{Table: "photos", Filters: map[string]string{"user_id": "photos.user_id"}})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	err = query.Apply(db.Preload("User").Preload("Renditions", orderRenditions)).Find(&Photos).Error
 
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -85,6 +173,24 @@ func PhotoGetAll(c *gin.Context) {
 		return
 	}
 
+	Photos, pagination := listquery.Page(query, Photos, func(p models.Photo) listquery.Key {
+		return listquery.KeyOf(p.GormModel)
+	})
+
+	photoIDs := make([]uint, len(Photos))
+	for i := range Photos {
+		photoIDs[i] = Photos[i].ID
+	}
+
+	likeCounts, likedByMe, err := loadLikes(db, uint(userData["id"].(float64)), photoIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to load likes",
+		})
+		return
+	}
+
 	for i := range Photos {
 		photo := make(map[string]interface{})
 		user := make(map[string]interface{})
@@ -96,15 +202,23 @@ func PhotoGetAll(c *gin.Context) {
 		photo["title"] = Photos[i].Title
 		photo["caption"] = Photos[i].Caption
 		photo["photo_url"] = Photos[i].PhotoUrl
+		photo["status"] = Photos[i].Status
+		photo["renditions"] = renditionList(Photos[i].Renditions)
+		photo["metadata"] = photoMetadata(Photos[i], uint(userData["id"].(float64)))
 		photo["user_id"] = Photos[i].UserId
 		photo["created_at"] = Photos[i].CreatedAt
 		photo["updated_at"] = Photos[i].UpdatedAt
+		photo["like_count"] = likeCounts[Photos[i].ID]
+		photo["liked_by_me"] = likedByMe[Photos[i].ID]
 		photo["User"] = user
 
 		data = append(data, photo)
 	}
 
-	c.JSON(http.StatusOK, data)
+	c.JSON(http.StatusOK, gin.H{
+		"data":       data,
+		"pagination": pagination,
+	})
 
 }
 
@@ -118,11 +232,12 @@ func PhotoGetAll(c *gin.Context) {
 // @Router       /photos/{photoId}   [get]
 func PhotoGetByID(c *gin.Context) {
 	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
 	photoID := c.Param("photoId")
 	var photo models.Photo
 	var data map[string]interface{}
 
-	if err := db.Preload("User").First(&photo, photoID).Error; err != nil {
+	if err := db.Preload("User").Preload("Renditions", orderRenditions).First(&photo, photoID).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Bad Request",
 			"message": err.Error(),
@@ -130,20 +245,34 @@ func PhotoGetByID(c *gin.Context) {
 		return
 	}
 
+	likeCounts, likedByMe, err := loadLikes(db, uint(userData["id"].(float64)), []uint{photo.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to load likes",
+		})
+		return
+	}
+
 	user := make(map[string]interface{})
 
 	user["email"] = photo.User.Email
 	user["username"] = photo.User.Username
 
 	data = map[string]interface{}{
-		"id":         photo.ID,
-		"title":      photo.Title,
-		"caption":    photo.Caption,
-		"photo_url":  photo.PhotoUrl,
-		"user_id":    photo.UserId,
-		"created_at": photo.CreatedAt,
-		"updated_at": photo.UpdatedAt,
-		"User":       user,
+		"id":          photo.ID,
+		"title":       photo.Title,
+		"caption":     photo.Caption,
+		"photo_url":   photo.PhotoUrl,
+		"status":      photo.Status,
+		"renditions":  renditionList(photo.Renditions),
+		"metadata":    photoMetadata(photo, uint(userData["id"].(float64))),
+		"user_id":     photo.UserId,
+		"created_at":  photo.CreatedAt,
+		"updated_at":  photo.UpdatedAt,
+		"like_count":  likeCounts[photo.ID],
+		"liked_by_me": likedByMe[photo.ID],
+		"User":        user,
 	}
 
 	c.JSON(http.StatusOK, data)
@@ -156,7 +285,7 @@ func PhotoGetByID(c *gin.Context) {
 // @Param        photoId   path      int  true  "Photo ID"
 // @Param        title formData string true "Photo's Title"
 // @Param        caption formData string true "Photo's Caption"
-// @Param        photo_url formData string true "Photo's Photo URL"
+// @Param        photo_url formData string false "Photo's Photo URL, required unless the photo was uploaded"
 // @Success      200  {object}  models.Photo
 // @Security    BearerAuth
 // @Router       /photos/{photoId}   [put]
@@ -176,11 +305,39 @@ func PhotoUpdate(c *gin.Context) {
 		c.ShouldBind(&Photo)
 	}
 
+	stored := models.Photo{}
+	if err := db.First(&stored, photoId).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "Photo not found",
+		})
+		return
+	}
+
+	// The URL of an uploaded photo points at its file, which the renditions
+	// and perceptual hash describe, so it cannot be swapped for another image.
+	if stored.MediaKey != "" {
+		if Photo.PhotoUrl != "" && Photo.PhotoUrl != stored.PhotoUrl && Photo.PhotoUrl != storage.URL(stored.MediaKey) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Bad Request",
+				"message": "The photo_url of an uploaded photo cannot be changed",
+			})
+			return
+		}
+
+		Photo.PhotoUrl = stored.PhotoUrl
+	}
+
 	Photo.UserId = userId
 	Photo.ID = uint(photoId)
 
 	err := db.Model(&Photo).Where("id = ?", photoId).Updates(models.Photo{Title: Photo.Title, Caption: Photo.Caption, PhotoUrl: Photo.PhotoUrl}).Error
 
+	// Moderators may edit photos of other users, so respond with the stored row.
+	if err == nil {
+		err = db.Preload("User").Preload("Renditions", orderRenditions).First(&Photo, photoId).Error
+	}
+
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Bad Request",
@@ -189,11 +346,14 @@ func PhotoUpdate(c *gin.Context) {
 		return
 	}
 	data = map[string]interface{}{
-		"id":        Photo.ID,
-		"title":     Photo.Title,
-		"caption":   Photo.Caption,
-		"photo_url": Photo.PhotoUrl,
-		"user_id":   Photo.UserId,
+		"id":         Photo.ID,
+		"title":      Photo.Title,
+		"caption":    Photo.Caption,
+		"photo_url":  Photo.PhotoUrl,
+		"status":     Photo.Status,
+		"renditions": renditionList(Photo.Renditions),
+		"metadata":   photoMetadata(Photo, userId),
+		"user_id":    Photo.UserId,
 	}
 	c.JSON(http.StatusOK, data)
 }
@@ -217,6 +377,11 @@ func PhotoDelete(c *gin.Context) {
 	Photo.UserId = userId
 	Photo.ID = uint(photoId)
 
+	mediaKeys := []string{}
+	renditionKeys := []string{}
+	db.Model(&models.Photo{}).Where("id = ?", photoId).Pluck("media_key", &mediaKeys)
+	db.Model(&models.PhotoRendition{}).Where("photo_id = ?", photoId).Pluck("media_key", &renditionKeys)
+
 	err := db.Delete(&Photo).Error
 
 	if err != nil {
@@ -227,6 +392,10 @@ func PhotoDelete(c *gin.Context) {
 		return
 	}
 
+	for _, key := range append(mediaKeys, renditionKeys...) {
+		removeUnusedMedia(c, db, key)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Your photo has been successfully deleted",
 	})
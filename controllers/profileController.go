@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"final-project/database"
+	"final-project/models"
+	"net/http"
+	"strconv"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetProfile godoc
+// @Summary      Get a user's profile
+// @Description  get the public profile of a user by ID; the age is only included when the user allows it
+// @Tags         User
+// @Param        userId   path      int  true  "User ID"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/{userId} [get]
+func UserGetProfile(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Invalid user id",
+		})
+
+		return
+	}
+
+	renderPublicProfile(c, database.GetDB().Where("id = ?", userID))
+}
+
+// GetProfileByUsername godoc
+// @Summary      Get a user's profile by username
+// @Description  get the public profile of a user by username; the age is only included when the user allows it
+// @Tags         User
+// @Param        username   path      string  true  "Username"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/by-username/{username} [get]
+func UserGetProfileByUsername(c *gin.Context) {
+	renderPublicProfile(c, database.GetDB().Where("username = ?", c.Param("username")))
+}
+
+// GetMe godoc
+// @Summary      Get the current user
+// @Description  get the full private profile of the current user
+// @Tags         User
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/me [get]
+func UserGetMe(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	userID := uint(userData["id"].(float64))
+	user := models.User{}
+
+	if err := db.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "User not found",
+		})
+
+		return
+	}
+
+	counts, err := profileCounts(db, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to count user content",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":                 user.ID,
+		"username":           user.Username,
+		"email":              user.Email,
+		"profile_image_url":  user.ProfileImageURL,
+		"age":                user.Age,
+		"show_age":           user.ShowAge,
+		"show_taken_at":      user.ShowTakenAt,
+		"show_location":      user.ShowLocation,
+		"role":               user.Role,
+		"verified_at":        user.VerifiedAt,
+		"two_factor_enabled": user.TotpEnabledAt != nil,
+		"created_at":         user.CreatedAt,
+		"updated_at":         user.UpdatedAt,
+		"counts":             counts,
+	})
+}
+
+func renderPublicProfile(c *gin.Context, query *gorm.DB) {
+	db := database.GetDB()
+	user := models.User{}
+
+	if err := query.First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "User not found",
+		})
+
+		return
+	}
+
+	counts, err := profileCounts(db, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to count user content",
+		})
+
+		return
+	}
+
+	profile := gin.H{
+		"id":                user.ID,
+		"username":          user.Username,
+		"profile_image_url": user.ProfileImageURL,
+		"created_at":        user.CreatedAt,
+		"counts":            counts,
+	}
+
+	if user.ShowAge {
+		profile["age"] = user.Age
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// profileCounts counts what a user has posted and their follows, for profile responses.
+func profileCounts(db *gorm.DB, userID uint) (gin.H, error) {
+	var photos, comments, socialMedias, followers, following int64
+
+	if err := db.Model(&models.Photo{}).Where("user_id = ?", userID).Count(&photos).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(&models.Comment{}).Where("user_id = ?", userID).Count(&comments).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(&models.SocialMedia{}).Where("user_id = ?", userID).Count(&socialMedias).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(&models.Follow{}).Where("followee_id = ?", userID).Count(&followers).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(&models.Follow{}).Where("follower_id = ?", userID).Count(&following).Error; err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"followers":     followers,
+		"following":     following,
+		"photos":        photos,
+		"comments":      comments,
+		"social_medias": socialMedias,
+	}, nil
+}
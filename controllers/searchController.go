@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"final-project/database"
+	"final-project/helpers"
+	"final-project/listquery"
+	"final-project/models"
+	"final-project/search"
+	"net/http"
+	"strconv"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+// Search godoc
+// @Summary      Search
+// @Description  full text search over photo titles and captions, comment messages or usernames; the last word also matches as a prefix and matches are wrapped in <mark></mark> in the snippet
+// @Tags         Search
+// @Param        q   query      string  true  "Search terms"
+// @Param        type   query      string  false  "photos (default), comments or users"
+// @Param        limit   query      int  false  "Page size, at most 50"
+// @Param        cursor   query      string  false  "next_cursor or prev_cursor of another page"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /search [get]
+func Search(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	userID := uint(userData["id"].(float64))
+	kind := c.DefaultQuery("type", search.KindPhotos)
+
+	if !search.ValidKind(kind) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": search.ErrInvalidKind.Error(),
+		})
+
+		return
+	}
+
+	// Each type is guarded by the scope of the resource it returns.
+	if scopes, isPAT := userData["scopes"].([]string); isPAT && !helpers.HasScope(scopes, kind, false) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "Forbidden",
+			"message": "This token does not have the required scope",
+		})
+
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 50 {
+		limit = 20
+	}
+
+	offset := 0
+	if cursor := c.Query("cursor"); cursor != "" {
+		if offset, err = search.DecodeCursor(cursor); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Bad Request",
+				"message": err.Error(),
+			})
+
+			return
+		}
+	}
+
+	req := search.Request{Kind: kind, Terms: search.Terms(c.Query("q")), Limit: limit + 1, Offset: offset}
+	if len(req.Terms) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": search.ErrEmptyQuery.Error(),
+		})
+
+		return
+	}
+
+	hits, err := search.GetSearcher(db).Search(db, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	pagination := listquery.Pagination{Limit: limit, Sort: "-rank"}
+
+	if len(hits) > limit {
+		hits = hits[:limit]
+		next := search.EncodeCursor(offset + limit)
+		pagination.NextCursor = &next
+	}
+
+	if offset > 0 {
+		prevOffset := offset - limit
+		if prevOffset < 0 {
+			prevOffset = 0
+		}
+
+		prev := search.EncodeCursor(prevOffset)
+		pagination.PrevCursor = &prev
+	}
+
+	ids := make([]uint, len(hits))
+	for i := range hits {
+		ids[i] = hits[i].ID
+	}
+
+	items := map[uint]gin.H{}
+
+	switch kind {
+	case search.KindPhotos:
+		photos := []models.Photo{}
+		err = db.Where("id IN ?", ids).Preload("Renditions", orderRenditions).Find(&photos).Error
+
+		if err == nil {
+			var likeCounts map[uint]int64
+			var likedByMe map[uint]bool
+
+			likeCounts, likedByMe, err = loadLikes(db, userID, ids)
+
+			for _, photo := range photos {
+				items[photo.ID] = gin.H{
+					"id":          photo.ID,
+					"title":       photo.Title,
+					"caption":     photo.Caption,
+					"photo_url":   photo.PhotoUrl,
+					"status":      photo.Status,
+					"renditions":  renditionList(photo.Renditions),
+					"user_id":     photo.UserId,
+					"created_at":  photo.CreatedAt,
+					"like_count":  likeCounts[photo.ID],
+					"liked_by_me": likedByMe[photo.ID],
+				}
+			}
+		}
+	case search.KindComments:
+		comments := []models.Comment{}
+		err = db.Where("id IN ?", ids).Find(&comments).Error
+
+		for _, comment := range comments {
+			items[comment.ID] = gin.H{
+				"id":         comment.ID,
+				"message":    comment.Message,
+				"photo_id":   comment.PhotoId,
+				"user_id":    comment.UserId,
+				"created_at": comment.CreatedAt,
+			}
+		}
+	case search.KindUsers:
+		users := []models.User{}
+		err = db.Select("id", "username", "profile_image_url").Where("id IN ?", ids).Find(&users).Error
+
+		for _, user := range users {
+			items[user.ID] = gin.H{
+				"id":                user.ID,
+				"username":          user.Username,
+				"profile_image_url": user.ProfileImageURL,
+			}
+		}
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to load search results",
+		})
+
+		return
+	}
+
+	// Keep the ranked order of the hits; rows deleted in between are skipped.
+	data := []interface{}{}
+	for _, hit := range hits {
+		if item, ok := items[hit.ID]; ok {
+			item["rank"] = hit.Rank
+			item["snippet"] = hit.Snippet
+			data = append(data, item)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       data,
+		"pagination": pagination,
+	})
+}
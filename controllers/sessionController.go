@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"final-project/database"
+	"final-project/models"
+	"net/http"
+	"strconv"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+// ListSessions godoc
+// @Summary      List login sessions
+// @Description  get the devices the current user is logged in on, newest activity first
+// @Tags         User
+// @Success      200  {object}  []models.Session
+// @Security    BearerAuth
+// @Router       /users/me/sessions [get]
+func SessionList(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	userID := uint(userData["id"].(float64))
+	currentSID, _ := userData["sid"].(string)
+	sessions := []models.Session{}
+	data := []interface{}{}
+
+	if err := db.Where("user_id = ? AND terminated_at IS NULL", userID).Order("last_seen_at desc").Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	for _, session := range sessions {
+		data = append(data, gin.H{
+			"id":           session.ID,
+			"user_agent":   session.UserAgent,
+			"ip":           session.Ip,
+			"last_seen_at": session.LastSeenAt,
+			"created_at":   session.CreatedAt,
+			"current":      currentSID != "" && session.FamilyId == currentSID,
+		})
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+// TerminateSession godoc
+// @Summary      Terminate a login session
+// @Description  log one of the current user's devices out; its access and refresh tokens stop working
+// @Tags         User
+// @Param        sessionId   path      int  true  "Session ID"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/me/sessions/{sessionId} [delete]
+func SessionTerminate(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	userID := uint(userData["id"].(float64))
+	session := models.Session{}
+
+	sessionID, err := strconv.Atoi(c.Param("sessionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Invalid session id",
+		})
+
+		return
+	}
+
+	if err := db.Where("id = ? AND user_id = ? AND terminated_at IS NULL", sessionID, userID).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "Session not found",
+		})
+
+		return
+	}
+
+	if err := revokeRefreshFamily(db, session.FamilyId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to terminate session",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The session has been successfully terminated",
+	})
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"final-project/database"
+	"final-project/helpers"
+	"final-project/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// hammingDistance counts the bits in which perceptual_hash differs from the
+// given hash.
+const hammingDistance = "length(replace(((photos.perceptual_hash # ?)::bit(64))::text, '0', ''))"
+
+type photoDistance struct {
+	ID       uint
+	Distance int
+}
+
+// similarPhotos selects the ids of photos whose perceptual hash is at most
+// maxDistance bits away from hash, closest first.
+func similarPhotos(db *gorm.DB, hash int64, maxDistance int) *gorm.DB {
+	return db.Model(&models.Photo{}).
+		Select("photos.id, "+hammingDistance+" AS distance", hash).
+		Where("photos.perceptual_hash IS NOT NULL AND "+hammingDistance+" <= ?", hash, maxDistance).
+		Order("distance, photos.id desc")
+}
+
+// duplicateDistance is DUPLICATE_PHOTO_DISTANCE, the number of differing bits
+// up to which two photos count as the same image.
+func duplicateDistance() int {
+	return helpers.GetEnvInt("DUPLICATE_PHOTO_DISTANCE", 5)
+}
+
+// findDuplicates returns the photos of userID that look like an upload with
+// the given hash, unless DUPLICATE_PHOTO_POLICY is off.
+func findDuplicates(db *gorm.DB, userID uint, hash *int64) ([]gin.H, error) {
+	duplicates := []gin.H{}
+
+	if hash == nil || helpers.GetEnv("DUPLICATE_PHOTO_POLICY", "warn") == "off" {
+		return duplicates, nil
+	}
+
+	matches := []photoDistance{}
+	if err := similarPhotos(db, *hash, duplicateDistance()).Where("photos.user_id = ?", userID).Limit(5).Scan(&matches).Error; err != nil {
+		return nil, err
+	}
+
+	for _, match := range matches {
+		duplicates = append(duplicates, gin.H{
+			"id":       match.ID,
+			"distance": match.Distance,
+		})
+	}
+
+	return duplicates, nil
+}
+
+// AdminSimilarPhotos godoc
+// @Summary      Find similar photos
+// @Description  find photos of any user that look like the given uploaded photo, to track down reposts
+// @Tags         Admin
+// @Param        photoId   path      int  true  "Photo ID"
+// @Param        distance query int false "Maximum number of differing hash bits, at most 32 (default DUPLICATE_PHOTO_DISTANCE)"
+// @Param        limit query int false "Number of photos, at most 100"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /admin/photos/{photoId}/similar [get]
+func AdminSimilarPhotos(c *gin.Context) {
+	db := database.GetDB()
+	photo := models.Photo{}
+	matches := []photoDistance{}
+	photos := []models.Photo{}
+	data := []gin.H{}
+
+	if err := db.First(&photo, c.Param("photoId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "Photo not found",
+		})
+
+		return
+	}
+
+	if photo.PerceptualHash == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Only uploaded photos can be compared",
+		})
+
+		return
+	}
+
+	distance, err := strconv.Atoi(c.DefaultQuery("distance", strconv.Itoa(duplicateDistance())))
+	if err != nil || distance < 0 || distance > 32 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "distance must be a number between 0 and 32",
+		})
+
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	err = similarPhotos(db, *photo.PerceptualHash, distance).Where("photos.id <> ?", photo.ID).Limit(limit).Scan(&matches).Error
+
+	if err == nil && len(matches) > 0 {
+		ids := make([]uint, len(matches))
+		for i := range matches {
+			ids[i] = matches[i].ID
+		}
+
+		err = db.Preload("User").Where("id IN ?", ids).Find(&photos).Error
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	byID := map[uint]models.Photo{}
+	for _, p := range photos {
+		byID[p.ID] = p
+	}
+
+	for _, match := range matches {
+		p, ok := byID[match.ID]
+		if !ok {
+			continue
+		}
+
+		user := gin.H{}
+		if p.User != nil {
+			user = gin.H{
+				"id":       p.User.ID,
+				"email":    p.User.Email,
+				"username": p.User.Username,
+			}
+		}
+
+		data = append(data, gin.H{
+			"id":         p.ID,
+			"title":      p.Title,
+			"photo_url":  p.PhotoUrl,
+			"user_id":    p.UserId,
+			"created_at": p.CreatedAt,
+			"distance":   match.Distance,
+			"User":       user,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"photo_id": photo.ID,
+		"distance": distance,
+		"data":     data,
+	})
+}
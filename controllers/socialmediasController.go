@@ -3,6 +3,7 @@ package controllers
 import (
 	"final-project/database"
 	"final-project/helpers"
+	"final-project/listquery"
 	"final-project/models"
 	"net/http"
 	"strconv"
@@ -109,18 +110,33 @@ func GetSocialMediaByID(c *gin.Context) {
 
 // Fetch godoc
 // @Summary      Fetch socialMedias
-// @Description  get socialMedias
+// @Description  get socialMedias one page at a time
 // @Tags         Social Media
-// @Success      200	{object}	[]models.SocialMedia
+// @Param        limit   query      int  false  "Page size, at most 100"
+// @Param        cursor   query      string  false  "next_cursor or prev_cursor of another page"
+// @Param        sort   query      string  false  "created_at, updated_at or id, prefixed with - for descending order (default -created_at)"
+// @Param        user_id   query      int  false  "Only items with this user id"
+// @Param        created_after   query      string  false  "Only items created at or after this RFC 3339 time"
+// @Param        created_before   query      string  false  "Only items created before this RFC 3339 time"
+// @Success      200	{object}	map[string]interface{}
 // @Security    BearerAuth
 // @Router       /socialmedias  [get]
 func SocialMediaList(c *gin.Context) {
 	db := database.GetDB()
 	var Socmed []models.SocialMedia
 
-	var data []interface{}
+	data := []interface{}{}
 
-	err := db.Preload("User").Find(&Socmed).Error
+	query, err := listquery.Parse(c, listquery.Options{Table: "social_medias", Filters: map[string]string{"user_id": "social_medias.user_id"}})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	err = query.Apply(db.Preload("User")).Find(&Socmed).Error
 
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -130,6 +146,10 @@ func SocialMediaList(c *gin.Context) {
 		return
 	}
 
+	Socmed, pagination := listquery.Page(query, Socmed, func(s models.SocialMedia) listquery.Key {
+		return listquery.KeyOf(s.GormModel)
+	})
+
 	for i := range Socmed {
 		sosmed := make(map[string]interface{})
 		user := make(map[string]interface{})
@@ -148,7 +168,10 @@ func SocialMediaList(c *gin.Context) {
 		data = append(data, sosmed)
 	}
 
-	c.JSON(http.StatusOK, data)
+	c.JSON(http.StatusOK, gin.H{
+		"data":       data,
+		"pagination": pagination,
+	})
 }
 
 // Update godoc
@@ -181,6 +204,11 @@ func SocialMediaUpdate(c *gin.Context) {
 
 	err := db.Model(&SocialMedia).Where("id = ?", socialMediaID).Updates(models.SocialMedia{Name: SocialMedia.Name, SocialMediaUrl: SocialMedia.SocialMediaUrl}).Error
 
+	// Moderators may edit entries of other users, so respond with the stored row.
+	if err == nil {
+		err = db.First(&SocialMedia, socialMediaID).Error
+	}
+
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Bad Request",
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"final-project/database"
+	"final-project/helpers"
+	"final-project/listquery"
+	"final-project/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+type trendingTag struct {
+	Name string `json:"name"`
+	Uses int64  `json:"uses"`
+}
+
+// TagPhotos godoc
+// @Summary      Fetch photos by hashtag
+// @Description  get the photos whose caption contains a hashtag, one page at a time
+// @Tags         Tag
+// @Param        tag   path      string  true  "Hashtag, with or without the leading #"
+// @Param        limit   query      int  false  "Page size, at most 100"
+// @Param        cursor   query      string  false  "next_cursor or prev_cursor of another page"
+// @Param        sort   query      string  false  "created_at, updated_at or id, prefixed with - for descending order (default -created_at)"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /tags/{tag}/photos [get]
+func TagPhotos(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	photos := []models.Photo{}
+	data := []interface{}{}
+
+	name := helpers.NormalizeHashtag(c.Param("tag"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Invalid tag",
+		})
+
+		return
+	}
+
+	query, err := listquery.Parse(c, listquery.Options{Table: "photos"})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	err = query.Apply(db.
+		Joins("JOIN photo_tags ON photo_tags.photo_id = photos.id").
+		Joins("JOIN tags ON tags.id = photo_tags.tag_id").
+		Where("tags.name = ?", name).
+		Preload("User").
+		Preload("Renditions", orderRenditions)).
+		Find(&photos).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	photos, pagination := listquery.Page(query, photos, func(p models.Photo) listquery.Key {
+		return listquery.KeyOf(p.GormModel)
+	})
+
+	photoIDs := make([]uint, len(photos))
+	for i := range photos {
+		photoIDs[i] = photos[i].ID
+	}
+
+	likeCounts, likedByMe, err := loadLikes(db, uint(userData["id"].(float64)), photoIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to load likes",
+		})
+
+		return
+	}
+
+	for _, photo := range photos {
+		user := gin.H{}
+		if photo.User != nil {
+			user = gin.H{
+				"email":    photo.User.Email,
+				"username": photo.User.Username,
+			}
+		}
+
+		data = append(data, gin.H{
+			"id":          photo.ID,
+			"title":       photo.Title,
+			"caption":     photo.Caption,
+			"photo_url":   photo.PhotoUrl,
+			"status":      photo.Status,
+			"renditions":  renditionList(photo.Renditions),
+			"metadata":    photoMetadata(photo, uint(userData["id"].(float64))),
+			"user_id":     photo.UserId,
+			"created_at":  photo.CreatedAt,
+			"updated_at":  photo.UpdatedAt,
+			"like_count":  likeCounts[photo.ID],
+			"liked_by_me": likedByMe[photo.ID],
+			"User":        user,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tag":        name,
+		"data":       data,
+		"pagination": pagination,
+	})
+}
+
+// TrendingTags godoc
+// @Summary      Trending hashtags
+// @Description  get the hashtags used most on photos and comments within a sliding window
+// @Tags         Tag
+// @Param        window   query      string  false  "Window such as 1h or 24h, at most 720h (default TRENDING_WINDOW)"
+// @Param        limit   query      int  false  "Number of tags, at most 50"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /tags/trending [get]
+func TrendingTags(c *gin.Context) {
+	db := database.GetDB()
+	tags := []trendingTag{}
+
+	window := helpers.GetEnvDuration("TRENDING_WINDOW", 24*time.Hour)
+	if value := c.Query("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 || parsed > 30*24*time.Hour {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Bad Request",
+				"message": "window must be a duration between 1s and 720h",
+			})
+
+			return
+		}
+
+		window = parsed
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	since := time.Now().Add(-window)
+	uses := db.Raw("? UNION ALL ?",
+		db.Model(&models.PhotoTag{}).Select("tag_id").Where("created_at >= ?", since),
+		db.Model(&models.CommentTag{}).Select("tag_id").Where("created_at >= ?", since),
+	)
+
+	err = db.Table("(?) AS uses", uses).
+		Select("tags.name, count(*) AS uses").
+		Joins("JOIN tags ON tags.id = uses.tag_id").
+		Group("tags.name").
+		Order("uses desc, tags.name").
+		Limit(limit).
+		Scan(&tags).Error
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"window": window.String(),
+		"since":  since,
+		"data":   tags,
+	})
+}
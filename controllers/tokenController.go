@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"errors"
+	"final-project/database"
+	"final-project/helpers"
+	"final-project/models"
+	"final-project/revocation"
+	"net/http"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type refreshInput struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token"`
+}
+
+var (
+	errRefreshInvalid = errors.New("Invalid refresh token")
+	errRefreshExpired = errors.New("Refresh token has expired")
+	errRefreshReused  = errors.New("Refresh token has already been used, all sessions of this login have been revoked")
+)
+
+// Refresh godoc
+// @Summary      Refresh an access token
+// @Description  exchange a refresh token for a new access token and a rotated refresh token
+// @Tags         User
+// @Param        refresh_token formData string true "Refresh Token"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /users/refresh [post]
+func UserRefresh(c *gin.Context) {
+	db := database.GetDB()
+	contentType := helpers.GetContentType(c)
+	input := refreshInput{}
+
+	if contentType == appJSON {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		if err := c.ShouldBind(&input); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if input.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Refresh token is required",
+		})
+
+		return
+	}
+
+	var tokens gin.H
+	stored := models.RefreshToken{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token_hash = ?", helpers.HashToken(input.RefreshToken)).First(&stored).Error; err != nil {
+			return errRefreshInvalid
+		}
+
+		if stored.RevokedAt != nil {
+			return errRefreshInvalid
+		}
+
+		if stored.RotatedAt != nil {
+			return errRefreshReused
+		}
+
+		if time.Now().After(stored.ExpiresAt) {
+			return errRefreshExpired
+		}
+
+		// Only one request may rotate a given token; a concurrent loser is treated as reuse.
+		res := tx.Model(&models.RefreshToken{}).Where("id = ? AND rotated_at IS NULL", stored.ID).Update("rotated_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			return errRefreshReused
+		}
+
+		user := models.User{}
+		if err := tx.First(&user, stored.UserId).Error; err != nil {
+			return errRefreshInvalid
+		}
+
+		// Families created before sessions were recorded get one on their first refresh.
+		now := time.Now()
+		session := models.Session{UserId: user.ID, FamilyId: stored.FamilyId, UserAgent: c.Request.UserAgent(), Ip: c.ClientIP(), LastSeenAt: &now}
+		if err := tx.Where("family_id = ?", stored.FamilyId).FirstOrCreate(&session).Error; err != nil {
+			return err
+		}
+
+		if session.TerminatedAt != nil {
+			return errRefreshInvalid
+		}
+
+		var err error
+		tokens, err = issueTokens(tx, user, stored.FamilyId)
+
+		return err
+	})
+
+	if err == errRefreshReused {
+		if err := revokeRefreshFamily(db, stored.FamilyId); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Internal Server Error",
+				"message": "Failed to revoke refresh tokens",
+			})
+
+			return
+		}
+	}
+
+	if err != nil {
+		status := http.StatusUnauthorized
+		if err != errRefreshInvalid && err != errRefreshExpired && err != errRefreshReused {
+			status = http.StatusInternalServerError
+		}
+
+		c.JSON(status, gin.H{
+			"error":   http.StatusText(status),
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout godoc
+// @Summary      Logout
+// @Description  revoke the current access token and end its session along with the refresh tokens issued for it
+// @Tags         User
+// @Param        refresh_token formData string false "Refresh Token"
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/logout [post]
+func UserLogout(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	contentType := helpers.GetContentType(c)
+	input := refreshInput{}
+
+	userID := uint(userData["id"].(float64))
+
+	if contentType == appJSON {
+		c.ShouldBindJSON(&input)
+	} else {
+		c.ShouldBind(&input)
+	}
+
+	if err := revocation.RevokeToken(userData); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to revoke token",
+		})
+
+		return
+	}
+
+	familyID, _ := userData["sid"].(string)
+
+	if familyID == "" && input.RefreshToken != "" {
+		stored := models.RefreshToken{}
+
+		if err := db.Where("token_hash = ? AND user_id = ?", helpers.HashToken(input.RefreshToken), userID).First(&stored).Error; err == nil {
+			familyID = stored.FamilyId
+		}
+	}
+
+	if familyID != "" {
+		if err := revokeRefreshFamily(db, familyID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Internal Server Error",
+				"message": "Failed to revoke refresh token",
+			})
+
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "You have been successfully logged out",
+	})
+}
+
+// LogoutAll godoc
+// @Summary      Logout everywhere
+// @Description  revoke every access, refresh and personal access token of the current user
+// @Tags         User
+// @Success      200  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/logout-all [post]
+func UserLogoutAll(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	userID := uint(userData["id"].(float64))
+
+	if err := revocation.RevokeToken(userData); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to revoke token",
+		})
+
+		return
+	}
+
+	if err := revocation.RevokeUser(db, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to revoke tokens",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "You have been successfully logged out from all devices",
+	})
+}
+
+// startSession records a new login session for the device making the request
+// and issues its first token pair.
+func startSession(c *gin.Context, db *gorm.DB, user models.User) (gin.H, error) {
+	familyID, err := helpers.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := models.Session{
+		UserId:     user.ID,
+		FamilyId:   familyID,
+		UserAgent:  c.Request.UserAgent(),
+		Ip:         c.ClientIP(),
+		LastSeenAt: &now,
+	}
+
+	if err := db.Create(&session).Error; err != nil {
+		return nil, err
+	}
+
+	return issueTokens(db, user, familyID)
+}
+
+// issueTokens signs a new access token for user and persists a refresh token
+// in the family of an existing session.
+func issueTokens(db *gorm.DB, user models.User, familyID string) (gin.H, error) {
+	refreshToken, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := models.RefreshToken{
+		UserId:    user.ID,
+		FamilyId:  familyID,
+		TokenHash: helpers.HashToken(refreshToken),
+		ExpiresAt: time.Now().Add(helpers.RefreshTokenTTL()),
+	}
+
+	if err := db.Create(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"token":         helpers.GenerateToken(user.ID, user.Email, user.Role, familyID),
+		"token_type":    "Bearer",
+		"expires_in":    int(helpers.AccessTokenTTL().Seconds()),
+		"refresh_token": refreshToken,
+	}, nil
+}
+
+// revokeRefreshFamily ends the session owning familyID along with its refresh
+// tokens; access tokens of the session are rejected by Authentication.
+func revokeRefreshFamily(db *gorm.DB, familyID string) error {
+	now := time.Now()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Session{}).
+			Where("family_id = ? AND terminated_at IS NULL", familyID).
+			Update("terminated_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", now).Error
+	})
+
+	if err != nil {
+		return err
+	}
+
+	revocation.RevokeSession(familyID)
+
+	return nil
+}
@@ -3,17 +3,35 @@ package controllers
 import (
 	"final-project/database"
 	"final-project/helpers"
+	"final-project/lockout"
 	"final-project/models"
+	"final-project/revocation"
+	"log"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 var appJSON = "application/json"
 
+type userUpdateInput struct {
+	Email           string  `json:"email" form:"email"`
+	Username        string  `json:"username" form:"username"`
+	ProfileImageURL string  `json:"profile_image_url" form:"profile_image_url"`
+	Age             int     `json:"age" form:"age"`
+	ShowAge         *bool   `json:"show_age" form:"show_age"`
+	ShowTakenAt     *bool   `json:"show_taken_at" form:"show_taken_at"`
+	ShowLocation    *bool   `json:"show_location" form:"show_location"`
+	Password        *string `json:"password" form:"password"`
+	CurrentPassword *string `json:"current_password" form:"current_password"`
+	NewPassword     *string `json:"new_password" form:"new_password"`
+}
+
 // Register godoc
 // @Summary      Create an user
 // @Description  create and store an user
@@ -42,6 +60,18 @@ func UserRegister(c *gin.Context) {
 		}
 	}
 
+	if err := helpers.ValidatePassword(user.Password, user.Username, user.Email); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	user.VerifiedAt = nil
+	user.Role = models.RoleUser
+
 	if err := db.Create(&user).Error; err != nil {
 		if strings.Contains(err.Error(), "duplicate key value violates unique constraint \"users_email_key\"") {
 			c.JSON(http.StatusConflict, gin.H{
@@ -69,6 +99,10 @@ func UserRegister(c *gin.Context) {
 		return
 	}
 
+	if err := sendVerificationEmail(db, user); err != nil {
+		log.Println("Failed to send verification email: ", err)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"age":               user.Age,
 		"email":             user.Email,
@@ -104,8 +138,33 @@ func UserLogin(c *gin.Context) {
 		}
 	}
 
+	guard := lockout.GetGuard()
+	email := user.Email
+	ip := c.ClientIP()
+
+	if wait, err := guard.Check(email, ip); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to check login attempts",
+		})
+
+		return
+	} else if wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   "Too Many Requests",
+			"message": "Too many failed login attempts, please try again later",
+		})
+
+		return
+	}
+
 	originalPassword := user.Password
 	if err := db.Where("email = ?", user.Email).First(&user).Take(&user).Error; err != nil {
+		if err := guard.Fail(email, ip); err != nil {
+			log.Println("Failed to record login attempt: ", err)
+		}
+
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "Not Found",
 			"message": "User not found",
@@ -115,6 +174,10 @@ func UserLogin(c *gin.Context) {
 	}
 
 	if isValid := helpers.CheckPasswordHash([]byte(user.Password), []byte(originalPassword)); !isValid {
+		if err := guard.Fail(email, ip); err != nil {
+			log.Println("Failed to record login attempt: ", err)
+		}
+
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error":   "Unauthorized",
 			"message": "Invalid email or password",
@@ -123,10 +186,41 @@ func UserLogin(c *gin.Context) {
 		return
 	}
 
-	jwt := helpers.GenerateToken(user.ID, user.Email)
-	c.JSON(http.StatusOK, gin.H{
-		"token": jwt,
-	})
+	// Hashes made with an older algorithm or cost are upgraded while the plaintext is at hand.
+	if helpers.PasswordNeedsRehash(user.Password) {
+		if hash, err := helpers.HashPassword(originalPassword); err != nil {
+			log.Println("Failed to rehash password: ", err)
+		} else if err := db.Model(&user).UpdateColumn("password", hash).Error; err != nil {
+			log.Println("Failed to store rehashed password: ", err)
+		}
+	}
+
+	// With two-factor authentication the password alone only earns a token for POST /users/login/mfa.
+	if user.TotpEnabledAt != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required": true,
+			"mfa_token":    helpers.GenerateMFAToken(user.ID),
+			"expires_in":   int(helpers.MFATokenTTL().Seconds()),
+		})
+
+		return
+	}
+
+	if err := guard.Succeed(email); err != nil {
+		log.Println("Failed to reset login attempts: ", err)
+	}
+
+	tokens, err := startSession(c, db, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to issue tokens",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
 
 }
 
@@ -139,8 +233,10 @@ func UserLogin(c *gin.Context) {
 // @Param        email formData string true "User's Email"
 // @Param        username formData string true "User's Username"
 // @Param        age formData int true "User's Age"
-// @Param        password formData string true "User's Password"
 // @Param        profile_image_url formData string true "User's Profile Image URL"
+// @Param        show_age formData bool false "Show the age on the public profile"
+// @Param        show_taken_at formData bool false "Show when photos were taken to other users"
+// @Param        show_location formData bool false "Show where photos were taken to other users"
 // @Success      200  {string}  models.User
 // @Security    BearerAuth
 // @Router       /users [put]
@@ -148,7 +244,8 @@ func UserUpdate(c *gin.Context) {
 	db := database.GetDB()
 	userData := c.MustGet("userData").(jwt.MapClaims)
 	contentType := helpers.GetContentType(c)
-	user := models.User{}
+	input := userUpdateInput{}
+	current := models.User{}
 
 	userid, err := strconv.Atoi(c.Param("userId"))
 	if err != nil {
@@ -162,20 +259,85 @@ func UserUpdate(c *gin.Context) {
 	userID := uint(userData["id"].(float64))
 
 	if contentType == appJSON {
-		if err := c.ShouldBindJSON(&user); err != nil {
+		if err := c.ShouldBindJSON(&input); err != nil {
 			c.AbortWithError(http.StatusBadRequest, err)
 			return
 		}
 	} else {
-		if err := c.ShouldBind(&user); err != nil {
+		if err := c.ShouldBind(&input); err != nil {
 			c.AbortWithError(http.StatusBadRequest, err)
 			return
 		}
 	}
 
+	// Passwords are only hashed on create, so they must never reach this update.
+	if input.Password != nil || input.CurrentPassword != nil || input.NewPassword != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "The password cannot be changed here, use PUT /users/me/password instead",
+		})
+
+		return
+	}
+
+	if err := db.First(&current, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "User not found",
+		})
+
+		return
+	}
+
+	user := models.User{
+		Username:        input.Username,
+		Email:           input.Email,
+		ProfileImageURL: input.ProfileImageURL,
+		Age:             input.Age,
+	}
 	user.ID = userID
+	emailChanged := input.Email != "" && !strings.EqualFold(input.Email, current.Email)
+
+	// A new address has to be verified again, so the email never changes
+	// without verified_at being cleared with it.
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Where("id = ?", userid).Updates(&user).Error; err != nil {
+			return err
+		}
 
-	err = db.Model(&user).Where("id = ?", userid).Updates(&user).First(&user).Error
+		if emailChanged {
+			if err := tx.Model(&user).Update("verified_at", nil).Error; err != nil {
+				return err
+			}
+		}
+
+		// Updates skips false, so the visibility settings are written on their own.
+		if input.ShowAge != nil {
+			if err := tx.Model(&user).Update("show_age", *input.ShowAge).Error; err != nil {
+				return err
+			}
+		}
+
+		if input.ShowTakenAt != nil {
+			if err := tx.Model(&user).Update("show_taken_at", *input.ShowTakenAt).Error; err != nil {
+				return err
+			}
+		}
+
+		if input.ShowLocation != nil {
+			if err := tx.Model(&user).Update("show_location", *input.ShowLocation).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.First(&user).Error
+	})
+
+	if err == nil && emailChanged {
+		if err := sendVerificationEmail(db, user); err != nil {
+			log.Println("Failed to send verification email: ", err)
+		}
+	}
 
 	if err != nil {
 		if strings.Contains(err.Error(), "duplicate key value violates unique constraint \"users_email_key\"") {
@@ -210,6 +372,9 @@ func UserUpdate(c *gin.Context) {
 		"username":          user.Username,
 		"updated_at":        user.UpdatedAt,
 		"age":               user.Age,
+		"show_age":          user.ShowAge,
+		"show_taken_at":     user.ShowTakenAt,
+		"show_location":     user.ShowLocation,
 		"profile_image_url": user.ProfileImageURL,
 	})
 
@@ -272,6 +437,15 @@ func UserDelete(ctx *gin.Context) {
 		return
 	}
 
+	// Mencabut semua token milik pengguna yang sudah dihapus
+	if err := revocation.RevokeUser(database.GetDB(), userID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to revoke user tokens",
+		})
+		return
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"message": "Your account has been successfully deleted",
 	})
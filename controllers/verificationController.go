@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"errors"
+	"final-project/database"
+	"final-project/helpers"
+	"final-project/mailer"
+	"final-project/models"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errAlreadyVerified = errors.New("Email is already verified")
+	errResendTooSoon   = errors.New("Please wait before requesting another verification email")
+)
+
+// VerifyEmail godoc
+// @Summary      Verify an email address
+// @Description  mark the user's email as verified using the token from the verification email
+// @Tags         User
+// @Param        token query string true "Verification Token"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /users/verify [get]
+func UserVerifyEmail(c *gin.Context) {
+	db := database.GetDB()
+	token := c.Query("token")
+
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Token is required",
+		})
+
+		return
+	}
+
+	verification := models.EmailVerificationToken{}
+	if err := db.Where("token_hash = ?", helpers.HashToken(token)).First(&verification).Error; err != nil || verification.UsedAt != nil || time.Now().After(verification.ExpiresAt) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Bad Request",
+			"message": "Invalid or expired verification token",
+		})
+
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		user := models.User{}
+
+		if err := tx.Model(&models.EmailVerificationToken{}).Where("user_id = ? AND used_at IS NULL", verification.UserId).Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		if err := tx.First(&user, verification.UserId).Error; err != nil {
+			return err
+		}
+
+		if user.VerifiedAt != nil {
+			return nil
+		}
+
+		return tx.Model(&user).Update("verified_at", now).Error
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to verify email",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Your email has been successfully verified",
+	})
+}
+
+// ResendVerification godoc
+// @Summary      Resend the verification email
+// @Description  send a new verification email to the current user
+// @Tags         User
+// @Success      200  {object}  map[string]interface{}
+// @Failure      429  {object}  map[string]interface{}
+// @Security    BearerAuth
+// @Router       /users/verify/resend [post]
+func UserResendVerification(c *gin.Context) {
+	db := database.GetDB()
+	userData := c.MustGet("userData").(jwt.MapClaims)
+	userID := uint(userData["id"].(float64))
+	user := models.User{}
+
+	var wait time.Duration
+
+	// Lock the user so concurrent requests see each other's token and only
+	// one of them sends an email.
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return err
+		}
+
+		if user.VerifiedAt != nil {
+			return errAlreadyVerified
+		}
+
+		last := models.EmailVerificationToken{}
+		if err := tx.Where("user_id = ?", user.ID).Order("created_at desc").First(&last).Error; err == nil && last.CreatedAt != nil {
+			wait = time.Until(last.CreatedAt.Add(helpers.GetEnvDuration("VERIFICATION_RESEND_INTERVAL", time.Minute)))
+
+			if wait > 0 {
+				return errResendTooSoon
+			}
+		}
+
+		return sendVerificationEmail(tx, user)
+	})
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "User not found",
+		})
+
+		return
+	case errors.Is(err, errAlreadyVerified):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Conflict",
+			"message": err.Error(),
+		})
+
+		return
+	case errors.Is(err, errResendTooSoon):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   "Too Many Requests",
+			"message": err.Error(),
+		})
+
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal Server Error",
+			"message": "Failed to send verification email",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "A new verification email has been sent",
+	})
+}
+
+func sendVerificationEmail(db *gorm.DB, user models.User) error {
+	token, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	err = db.Create(&models.EmailVerificationToken{
+		UserId:    user.ID,
+		TokenHash: helpers.HashToken(token),
+		ExpiresAt: time.Now().Add(helpers.GetEnvDuration("VERIFICATION_TOKEN_TTL", 24*time.Hour)),
+	}).Error
+
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s?token=%s", helpers.GetEnv("VERIFICATION_URL", "http://localhost:8080/users/verify"), token)
+
+	return mailer.GetSender().Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body:    fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below.\n\n%s", user.Username, link),
+	})
+}
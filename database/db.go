@@ -24,14 +24,47 @@ func StartDB() {
 	}
 	config := fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=disable", os.Getenv("PGHOST"), os.Getenv("PGPORT"), os.Getenv("PGUSER"), os.Getenv("PGDATABASE"), os.Getenv("PGPASSWORD"))
 
-	db, err = gorm.Open(postgres.Open(config), &gorm.Config{})
-
-	if err != nil {
+	if err := Connect(config); err != nil {
 		log.Fatal("Error connecting to database: ", err)
 	}
 
 	fmt.Println("Successfully connected to database")
-	db.Debug().AutoMigrate(&models.User{}, &models.Photo{}, &models.SocialMedia{}, &models.Comment{})
+}
+
+// Connect opens and migrates the database at dsn. Tests call it with
+// TEST_DATABASE_URL instead of going through the .env file.
+func Connect(dsn string) error {
+	db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+
+	if err != nil {
+		return err
+	}
+
+	err = db.Debug().AutoMigrate(&models.User{}, &models.Photo{}, &models.SocialMedia{}, &models.Comment{}, &models.RefreshToken{}, &models.RevokedToken{}, &models.PasswordResetToken{}, &models.EmailVerificationToken{}, &models.LoginAttempt{}, &models.RecoveryCode{}, &models.PersonalAccessToken{}, &models.AuditLog{}, &models.Session{}, &models.UserIdentity{}, &models.AuthorizationRequest{}, &models.Follow{}, &models.Like{}, &models.Tag{}, &models.PhotoTag{}, &models.CommentTag{}, &models.PhotoRendition{}, &models.Album{}, &models.AlbumPhoto{})
+
+	if err != nil {
+		return err
+	}
+
+	indexes := []string{
+		// The feed reads each followed author's photos newest first.
+		"CREATE INDEX IF NOT EXISTS idx_photos_user_id_created_at_id ON photos (user_id, created_at DESC, id DESC)",
+		// Albums are listed per user, newest first.
+		"CREATE INDEX IF NOT EXISTS idx_albums_user_id_created_at_id ON albums (user_id, created_at DESC, id DESC)",
+	}
+
+	// Keyset pagination of the lists in their default order.
+	for _, table := range []string{"photos", "comments", "social_medias"} {
+		indexes = append(indexes, fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_created_at_id ON %s (created_at DESC, id DESC)", table, table))
+	}
+
+	for _, index := range indexes {
+		if err := db.Exec(index).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func GetDB() *gorm.DB {
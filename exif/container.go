@@ -0,0 +1,117 @@
+package exif
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// errStop ends a walk early when a callback returns false.
+var errStop = errors.New("stop")
+
+var (
+	exifHeader   = []byte("Exif\x00\x00")
+	pngSignature = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// walkJPEG calls fn for every marker segment before the image data with the
+// segment payload and its raw bytes, marker included. It returns the offset
+// of the start of scan marker, after which only image data follows.
+func walkJPEG(data []byte, fn func(marker byte, payload, raw []byte) bool) (int, error) {
+	pos := 2
+
+	for pos+2 <= len(data) {
+		if data[pos] != 0xff {
+			return 0, ErrMalformed
+		}
+
+		marker := data[pos+1]
+
+		switch {
+		case marker == 0xff:
+			// Fill byte before a marker.
+			pos++
+			continue
+		case marker == 0xda || marker == 0xd9:
+			return pos, nil
+		case marker == 0x01 || marker >= 0xd0 && marker <= 0xd8:
+			if !fn(marker, nil, data[pos:pos+2]) {
+				return pos, errStop
+			}
+
+			pos += 2
+			continue
+		}
+
+		if pos+4 > len(data) {
+			return 0, ErrMalformed
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return 0, ErrMalformed
+		}
+
+		if !fn(marker, data[pos+4:pos+2+length], data[pos:pos+2+length]) {
+			return pos, errStop
+		}
+
+		pos += 2 + length
+	}
+
+	return 0, ErrMalformed
+}
+
+// walkPNG calls fn for every chunk up to and including IEND.
+func walkPNG(data []byte, fn func(kind string, payload, raw []byte) bool) error {
+	pos := len(pngSignature)
+
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		if length < 0 || length > len(data)-pos-12 {
+			return ErrMalformed
+		}
+
+		kind := string(data[pos+4 : pos+8])
+		if !fn(kind, data[pos+8:pos+8+length], data[pos:pos+12+length]) {
+			return errStop
+		}
+
+		pos += 12 + length
+
+		if kind == "IEND" {
+			return nil
+		}
+	}
+
+	return ErrMalformed
+}
+
+// walkWebP calls fn for every chunk of the RIFF container. Raw chunks include
+// their padding byte.
+func walkWebP(data []byte, fn func(kind string, payload, raw []byte) bool) error {
+	pos := 12
+
+	for pos+8 <= len(data) {
+		length := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		if length < 0 || length > len(data)-pos-8 {
+			return ErrMalformed
+		}
+
+		end := pos + 8 + length
+		if length%2 == 1 && end < len(data) {
+			end++
+		}
+
+		if !fn(string(data[pos:pos+4]), data[pos+8:pos+8+length], data[pos:end]) {
+			return errStop
+		}
+
+		pos = end
+	}
+
+	if pos != len(data) {
+		return ErrMalformed
+	}
+
+	return nil
+}
@@ -0,0 +1,355 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoMetadata = errors.New("no EXIF metadata found")
+	ErrMalformed  = errors.New("malformed EXIF metadata")
+)
+
+// Metadata holds the EXIF fields kept for photos. Orientation is 1 (upright)
+// when the image does not say otherwise.
+type Metadata struct {
+	TakenAt     *time.Time
+	Make        string
+	Model       string
+	Orientation int
+	Latitude    *float64
+	Longitude   *float64
+}
+
+// Camera is the model prefixed with the make, unless the model already
+// starts with it as many manufacturers do.
+func (m Metadata) Camera() string {
+	if m.Make == "" || strings.HasPrefix(strings.ToLower(m.Model), strings.ToLower(m.Make)) {
+		return m.Model
+	}
+
+	if m.Model == "" {
+		return m.Make
+	}
+
+	return m.Make + " " + m.Model
+}
+
+// Tags read from IFD0, the Exif IFD and the GPS IFD.
+const (
+	tagMake               = 0x010f
+	tagModel              = 0x0110
+	tagOrientation        = 0x0112
+	tagDateTime           = 0x0132
+	tagExifIFD            = 0x8769
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+	tagGPSLatitudeRef     = 0x0001
+	tagGPSLatitude        = 0x0002
+	tagGPSLongitudeRef    = 0x0003
+	tagGPSLongitude       = 0x0004
+)
+
+// TIFF field types and their sizes in bytes.
+var typeSizes = map[uint16]uint32{
+	1:  1, // BYTE
+	2:  1, // ASCII
+	3:  2, // SHORT
+	4:  4, // LONG
+	5:  8, // RATIONAL
+	7:  1, // UNDEFINED
+	9:  4, // SLONG
+	10: 8, // SRATIONAL
+}
+
+type entry struct {
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// Parse reads the EXIF metadata of a JPEG, PNG or WebP image or of a TIFF
+// file. Images without metadata return ErrNoMetadata.
+func Parse(data []byte) (Metadata, error) {
+	raw, err := Find(data)
+	if err != nil {
+		return Metadata{Orientation: 1}, err
+	}
+
+	return ParseTIFF(raw)
+}
+
+// Find returns the TIFF structure holding the EXIF metadata of an image.
+func Find(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8}):
+		return findJPEG(data)
+	case bytes.HasPrefix(data, pngSignature):
+		return findPNG(data)
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return findWebP(data)
+	case bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*")):
+		return data, nil
+	}
+
+	return nil, ErrNoMetadata
+}
+
+func findJPEG(data []byte) ([]byte, error) {
+	var found []byte
+
+	_, err := walkJPEG(data, func(marker byte, payload, raw []byte) bool {
+		if marker == 0xe1 && bytes.HasPrefix(payload, exifHeader) {
+			found = payload[len(exifHeader):]
+			return false
+		}
+
+		return true
+	})
+
+	if found != nil {
+		return found, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return nil, ErrNoMetadata
+}
+
+func findPNG(data []byte) ([]byte, error) {
+	var found []byte
+
+	err := walkPNG(data, func(kind string, chunk, raw []byte) bool {
+		if kind == "eXIf" {
+			found = chunk
+			return false
+		}
+
+		return true
+	})
+
+	if found != nil {
+		return found, nil
+	} else if err != nil && err != errStop {
+		return nil, err
+	}
+
+	return nil, ErrNoMetadata
+}
+
+func findWebP(data []byte) ([]byte, error) {
+	var found []byte
+
+	err := walkWebP(data, func(kind string, chunk, raw []byte) bool {
+		if kind == "EXIF" {
+			// Some writers keep the JPEG APP1 header in the chunk.
+			found = bytes.TrimPrefix(chunk, exifHeader)
+			return false
+		}
+
+		return true
+	})
+
+	if found != nil {
+		return found, nil
+	} else if err != nil && err != errStop {
+		return nil, err
+	}
+
+	return nil, ErrNoMetadata
+}
+
+// ParseTIFF reads the metadata of a TIFF structure as found in the APP1
+// segment of a JPEG file.
+func ParseTIFF(data []byte) (Metadata, error) {
+	meta := Metadata{Orientation: 1}
+
+	if len(data) < 8 {
+		return meta, ErrMalformed
+	}
+
+	t := tiff{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return meta, ErrMalformed
+	}
+
+	if t.order.Uint16(data[2:4]) != 42 {
+		return meta, ErrMalformed
+	}
+
+	ifd0, err := t.ifd(t.order.Uint32(data[4:8]))
+	if err != nil {
+		return meta, err
+	}
+
+	meta.Make = ifd0.ascii(tagMake)
+	meta.Model = ifd0.ascii(tagModel)
+
+	if orientation, ok := ifd0.uint(t, tagOrientation); ok && orientation >= 1 && orientation <= 8 {
+		meta.Orientation = int(orientation)
+	}
+
+	taken := ifd0.ascii(tagDateTime)
+	offset := ""
+
+	if pointer, ok := ifd0.uint(t, tagExifIFD); ok {
+		if exifIFD, err := t.ifd(pointer); err == nil {
+			if original := exifIFD.ascii(tagDateTimeOriginal); original != "" {
+				taken = original
+				offset = exifIFD.ascii(tagOffsetTimeOriginal)
+			}
+		}
+	}
+
+	meta.TakenAt = parseDateTime(taken, offset)
+
+	if pointer, ok := ifd0.uint(t, tagGPSIFD); ok {
+		if gps, err := t.ifd(pointer); err == nil {
+			meta.Latitude = gps.coordinate(t, tagGPSLatitude, tagGPSLatitudeRef, "S", 90)
+			meta.Longitude = gps.coordinate(t, tagGPSLongitude, tagGPSLongitudeRef, "W", 180)
+		}
+	}
+
+	return meta, nil
+}
+
+type ifd map[uint16]entry
+
+// ifd reads the directory at offset. Entries whose value lies outside the
+// data are skipped rather than failing the whole directory.
+func (t tiff) ifd(offset uint32) (ifd, error) {
+	if uint64(offset)+2 > uint64(len(t.data)) {
+		return nil, ErrMalformed
+	}
+
+	count := uint32(t.order.Uint16(t.data[offset:]))
+	if uint64(offset)+2+uint64(count)*12 > uint64(len(t.data)) {
+		return nil, ErrMalformed
+	}
+
+	entries := ifd{}
+
+	for i := uint32(0); i < count; i++ {
+		raw := t.data[offset+2+i*12 : offset+2+i*12+12]
+		typ := t.order.Uint16(raw[2:4])
+		size, ok := typeSizes[typ]
+		if !ok {
+			continue
+		}
+
+		e := entry{typ: typ, count: t.order.Uint32(raw[4:8])}
+		length := uint64(size) * uint64(e.count)
+
+		if length <= 4 {
+			e.value = raw[8 : 8+length]
+		} else {
+			start := uint64(t.order.Uint32(raw[8:12]))
+			if start+length > uint64(len(t.data)) {
+				continue
+			}
+
+			e.value = t.data[start : start+length]
+		}
+
+		entries[t.order.Uint16(raw[0:2])] = e
+	}
+
+	return entries, nil
+}
+
+func (d ifd) ascii(tag uint16) string {
+	e, ok := d[tag]
+	if !ok || (e.typ != 2 && e.typ != 7) {
+		return ""
+	}
+
+	value := e.value
+	if i := bytes.IndexByte(value, 0); i >= 0 {
+		value = value[:i]
+	}
+
+	return strings.TrimSpace(strings.ToValidUTF8(string(value), ""))
+}
+
+func (d ifd) uint(t tiff, tag uint16) (uint32, bool) {
+	e, ok := d[tag]
+	if !ok || e.count == 0 {
+		return 0, false
+	}
+
+	switch e.typ {
+	case 3:
+		return uint32(t.order.Uint16(e.value)), true
+	case 4:
+		return t.order.Uint32(e.value), true
+	}
+
+	return 0, false
+}
+
+// coordinate reads degrees, minutes and seconds stored as three rationals.
+func (d ifd) coordinate(t tiff, tag, refTag uint16, negative string, limit float64) *float64 {
+	e, ok := d[tag]
+	if !ok || e.typ != 5 || e.count != 3 {
+		return nil
+	}
+
+	value := 0.0
+	for i, scale := range []float64{1, 60, 3600} {
+		numerator := t.order.Uint32(e.value[i*8:])
+		denominator := t.order.Uint32(e.value[i*8+4:])
+
+		if denominator == 0 {
+			return nil
+		}
+
+		value += float64(numerator) / float64(denominator) / scale
+	}
+
+	if strings.EqualFold(d.ascii(refTag), negative) {
+		value = -value
+	}
+
+	if value < -limit || value > limit {
+		return nil
+	}
+
+	return &value
+}
+
+// parseDateTime reads "2006:01:02 15:04:05". Cameras record local time; the
+// offset is only known when OffsetTimeOriginal is present, otherwise the
+// time is taken as UTC.
+func parseDateTime(value, offset string) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	location := time.UTC
+	if offset != "" {
+		if zone, err := time.Parse("-07:00", offset); err == nil {
+			location = zone.Location()
+		}
+	}
+
+	t, err := time.ParseInLocation("2006:01:02 15:04:05", value, location)
+	if err != nil || t.Year() < 1800 {
+		return nil
+	}
+
+	return &t
+}
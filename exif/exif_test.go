@@ -0,0 +1,268 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+type field struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+// tiffBuilder writes TIFF structures: every directory is followed by the
+// values that do not fit in its entries.
+type tiffBuilder struct {
+	order byteOrder
+	buf   []byte
+}
+
+func newTIFF(order byteOrder) *tiffBuilder {
+	b := &tiffBuilder{order: order}
+	if order == binary.LittleEndian {
+		b.buf = []byte("II*\x00\x00\x00\x00\x00")
+	} else {
+		b.buf = []byte("MM\x00*\x00\x00\x00\x00")
+	}
+
+	return b
+}
+
+// ifd appends a directory of fields and returns its offset.
+func (b *tiffBuilder) ifd(fields ...field) uint32 {
+	offset := uint32(len(b.buf))
+	values := offset + 2 + uint32(len(fields))*12 + 4
+	extra := []byte{}
+
+	b.buf = b.order.AppendUint16(b.buf, uint16(len(fields)))
+	for _, f := range fields {
+		b.buf = b.order.AppendUint16(b.buf, f.tag)
+		b.buf = b.order.AppendUint16(b.buf, f.typ)
+		b.buf = b.order.AppendUint32(b.buf, f.count)
+
+		if len(f.value) <= 4 {
+			b.buf = append(b.buf, f.value...)
+			b.buf = append(b.buf, make([]byte, 4-len(f.value))...)
+			continue
+		}
+
+		b.buf = b.order.AppendUint32(b.buf, values+uint32(len(extra)))
+		extra = append(extra, f.value...)
+	}
+
+	b.buf = append(b.buf, 0, 0, 0, 0)
+	b.buf = append(b.buf, extra...)
+
+	return offset
+}
+
+// bytes returns the structure with ifd0 as the first directory.
+func (b *tiffBuilder) bytes(ifd0 uint32) []byte {
+	b.order.PutUint32(b.buf[4:8], ifd0)
+
+	return b.buf
+}
+
+func ascii(tag uint16, value string) field {
+	return field{tag: tag, typ: 2, count: uint32(len(value) + 1), value: append([]byte(value), 0)}
+}
+
+func (b *tiffBuilder) short(tag uint16, value uint16) field {
+	return field{tag: tag, typ: 3, count: 1, value: b.order.AppendUint16(nil, value)}
+}
+
+func (b *tiffBuilder) long(tag uint16, value uint32) field {
+	return field{tag: tag, typ: 4, count: 1, value: b.order.AppendUint32(nil, value)}
+}
+
+func (b *tiffBuilder) rationals(tag uint16, values ...uint32) field {
+	f := field{tag: tag, typ: 5, count: uint32(len(values) / 2)}
+	for _, value := range values {
+		f.value = b.order.AppendUint32(f.value, value)
+	}
+
+	return f
+}
+
+// sampleTIFF has a camera, orientation 6, a capture time with its offset and
+// a position in the southern and western hemispheres.
+func sampleTIFF(order byteOrder) []byte {
+	b := newTIFF(order)
+	gps := b.ifd(
+		ascii(tagGPSLatitudeRef, "S"),
+		b.rationals(tagGPSLatitude, 33, 1, 51, 1, 3240, 100),
+		ascii(tagGPSLongitudeRef, "W"),
+		b.rationals(tagGPSLongitude, 70, 1, 39, 1, 36, 1),
+	)
+	exifIFD := b.ifd(
+		ascii(tagDateTimeOriginal, "2023:04:05 06:07:08"),
+		ascii(tagOffsetTimeOriginal, "-03:00"),
+	)
+	ifd0 := b.ifd(
+		ascii(tagMake, "Canon"),
+		ascii(tagModel, "Canon EOS R6"),
+		b.short(tagOrientation, 6),
+		ascii(tagDateTime, "2024:01:01 00:00:00"),
+		b.long(tagExifIFD, exifIFD),
+		b.long(tagGPSIFD, gps),
+	)
+
+	return b.bytes(ifd0)
+}
+
+func TestParseTIFF(t *testing.T) {
+	for _, order := range []byteOrder{binary.LittleEndian, binary.BigEndian} {
+		meta, err := ParseTIFF(sampleTIFF(order))
+		if err != nil {
+			t.Fatalf("%s: %s", order, err)
+		}
+
+		if meta.Camera() != "Canon EOS R6" || meta.Orientation != 6 {
+			t.Errorf("%s: camera %q, orientation %d", order, meta.Camera(), meta.Orientation)
+		}
+
+		taken := time.Date(2023, 4, 5, 9, 7, 8, 0, time.UTC)
+		if meta.TakenAt == nil || !meta.TakenAt.Equal(taken) {
+			t.Errorf("%s: taken at %v, want %s", order, meta.TakenAt, taken)
+		}
+
+		// 33° 51' 32.4" S and 70° 39' 36" W.
+		if meta.Latitude == nil || math.Abs(*meta.Latitude-(-33.859)) > 1e-9 {
+			t.Errorf("%s: latitude %v, want -33.859", order, meta.Latitude)
+		}
+
+		if meta.Longitude == nil || math.Abs(*meta.Longitude-(-70.66)) > 1e-9 {
+			t.Errorf("%s: longitude %v, want -70.66", order, meta.Longitude)
+		}
+	}
+}
+
+func TestParseTIFFCoordinates(t *testing.T) {
+	tests := []struct {
+		name   string
+		ref    string
+		values []uint32
+		want   *float64
+	}{
+		{"north", "N", []uint32{48, 1, 51, 1, 2700, 100}, ptr(48.8575)},
+		{"lowercase south", "s", []uint32{48, 1, 51, 1, 2700, 100}, ptr(-48.8575)},
+		{"fractional degrees", "N", []uint32{4512, 100, 0, 1, 0, 1}, ptr(45.12)},
+		{"zero denominator", "N", []uint32{48, 0, 51, 1, 29, 1}, nil},
+		{"beyond the pole", "N", []uint32{91, 1, 0, 1, 0, 1}, nil},
+		{"two values", "N", []uint32{48, 1, 51, 1}, nil},
+	}
+
+	for _, tt := range tests {
+		b := newTIFF(binary.BigEndian)
+		gps := b.ifd(ascii(tagGPSLatitudeRef, tt.ref), b.rationals(tagGPSLatitude, tt.values...))
+		meta, err := ParseTIFF(b.bytes(b.ifd(b.long(tagGPSIFD, gps))))
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+
+		switch {
+		case tt.want == nil && meta.Latitude != nil:
+			t.Errorf("%s: latitude %v, want none", tt.name, *meta.Latitude)
+		case tt.want != nil && (meta.Latitude == nil || math.Abs(*meta.Latitude-*tt.want) > 1e-9):
+			t.Errorf("%s: latitude %v, want %v", tt.name, meta.Latitude, *tt.want)
+		}
+	}
+}
+
+func ptr(value float64) *float64 {
+	return &value
+}
+
+func TestParseTIFFMalformed(t *testing.T) {
+	le := binary.LittleEndian
+
+	tests := map[string][]byte{
+		"empty":                 nil,
+		"short header":          []byte("II*\x00\x08"),
+		"unknown byte order":    []byte("XX*\x00\x08\x00\x00\x00\x00\x00"),
+		"wrong magic":           []byte("II+\x00\x08\x00\x00\x00\x00\x00"),
+		"ifd0 past the end":     []byte("II*\x00\x09\x00\x00\x00\x00\x00"),
+		"ifd0 at max offset":    []byte("II*\x00\xff\xff\xff\xff"),
+		"entry count too large": []byte("II*\x00\x08\x00\x00\x00\xff\xff"),
+		"truncated entry":       append([]byte("II*\x00\x08\x00\x00\x00\x01\x00"), make([]byte, 11)...),
+	}
+
+	for name, data := range tests {
+		if _, err := ParseTIFF(data); err != ErrMalformed {
+			t.Errorf("%s: error = %v, want ErrMalformed", name, err)
+		}
+	}
+
+	// Values and directories the entries point outside the data are left
+	// out instead of failing the whole structure.
+	b := newTIFF(le)
+	ifd0 := b.ifd(
+		field{tag: tagMake, typ: 2, count: 100, value: le.AppendUint32(nil, 0xfffffff0)},
+		field{tag: tagModel, typ: 2, count: 0xffffffff, value: le.AppendUint32(nil, 8)},
+		field{tag: tagOrientation, typ: 3, count: 0, value: nil},
+		field{tag: tagDateTime, typ: 99, count: 1, value: []byte("x")},
+		b.long(tagExifIFD, 0xffffffff),
+		b.long(tagGPSIFD, 3),
+	)
+
+	meta, err := ParseTIFF(b.bytes(ifd0))
+	if err != nil {
+		t.Fatalf("out of range values: %s", err)
+	}
+
+	if meta != (Metadata{Orientation: 1}) {
+		t.Fatalf("out of range values: %+v", meta)
+	}
+}
+
+// TestTruncated cuts images with metadata at every length. Nothing may
+// panic, and broken metadata containers have to be reported.
+func TestTruncated(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"jpeg": sampleJPEG(t),
+		"png":  samplePNG(t),
+		"webp": sampleWebP(),
+		"tiff": sampleTIFF(binary.BigEndian),
+	} {
+		for n := 0; n < len(data); n++ {
+			Parse(data[:n])
+			Strip(data[:n], 1)
+			Strip(data[:n], 6)
+		}
+
+		if _, err := Parse(data); err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+	}
+
+	broken := map[string][]byte{
+		"jpeg segment past the end": []byte("\xff\xd8\xff\xe1\x10\x00Exif\x00\x00"),
+		"jpeg without a marker":     []byte("\xff\xd8\x00\x00"),
+		"png chunk past the end":    append(append([]byte{}, pngSignature...), "\x7f\xff\xff\xffeXIf"+string(make([]byte, 8))...),
+		"webp chunk past the end":   []byte("RIFF\x00\x00\x00\x00WEBPEXIF\xff\xff\xff\x7f"),
+	}
+
+	for name, data := range broken {
+		if _, err := Parse(data); err != ErrMalformed {
+			t.Errorf("parse %s: error = %v, want ErrMalformed", name, err)
+		}
+
+		if _, err := Strip(data, 1); err != ErrMalformed {
+			t.Errorf("strip %s: error = %v, want ErrMalformed", name, err)
+		}
+	}
+
+	if _, err := Parse(bytes.Repeat([]byte{0xff}, 64)); err != ErrNoMetadata {
+		t.Errorf("unknown format: error = %v, want ErrNoMetadata", err)
+	}
+}
@@ -0,0 +1,59 @@
+package exif
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestOrient(t *testing.T) {
+	// A 3x2 image whose pixels are numbered like this:
+	//
+	//	0 1 2
+	//	3 4 5
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for i := 0; i < 6; i++ {
+		src.Set(i%3, i/3, color.NRGBA{R: uint8(i), A: 255})
+	}
+
+	tests := map[int][][]uint8{
+		1: {{0, 1, 2}, {3, 4, 5}},
+		2: {{2, 1, 0}, {5, 4, 3}},
+		3: {{5, 4, 3}, {2, 1, 0}},
+		4: {{3, 4, 5}, {0, 1, 2}},
+		5: {{0, 3}, {1, 4}, {2, 5}},
+		6: {{3, 0}, {4, 1}, {5, 2}},
+		7: {{5, 2}, {4, 1}, {3, 0}},
+		8: {{2, 5}, {1, 4}, {0, 3}},
+		0: {{0, 1, 2}, {3, 4, 5}},
+		9: {{0, 1, 2}, {3, 4, 5}},
+	}
+
+	for orientation, want := range tests {
+		img := Orient(src, orientation)
+		bounds := img.Bounds()
+
+		got := [][]uint8{}
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			row := []uint8{}
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				row = append(row, color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA).R)
+			}
+
+			got = append(got, row)
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("orientation %d: %v, want %v", orientation, got, want)
+		}
+	}
+}
+
+func TestOrientOffsetBounds(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 4)).SubImage(image.Rect(2, 1, 4, 4))
+
+	if got := Orient(src, 6).Bounds(); got != image.Rect(0, 0, 3, 2) {
+		t.Fatalf("bounds = %v, want a 3x2 image at the origin", got)
+	}
+}
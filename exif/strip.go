@@ -0,0 +1,259 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"final-project/imaging"
+	"image/jpeg"
+	"image/png"
+)
+
+// jpegQuality is used when a JPEG has to be re-encoded to turn it upright.
+const jpegQuality = 92
+
+// Strip removes EXIF, XMP, comments and similar metadata from a JPEG, PNG,
+// GIF or WebP image, keeping the pixels and color profile untouched. JPEG and
+// PNG images that are not upright according to orientation are decoded,
+// turned and encoded again instead, unless they exceed imaging.MaxPixels.
+// Other data is returned as is.
+func Strip(data []byte, orientation int) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8}):
+		if orientation > 1 {
+			return reorientJPEG(data, orientation)
+		}
+
+		return stripJPEG(data)
+	case bytes.HasPrefix(data, pngSignature):
+		if orientation > 1 {
+			return reorientPNG(data, orientation)
+		}
+
+		return stripPNG(data)
+	case bytes.HasPrefix(data, []byte("GIF87a")) || bytes.HasPrefix(data, []byte("GIF89a")):
+		return stripGIF(data)
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return stripWebP(data)
+	}
+
+	return data, nil
+}
+
+func reorientJPEG(data []byte, orientation int) ([]byte, error) {
+	if _, _, err := imaging.DecodeConfig(data); err != nil {
+		return nil, err
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	out := bytes.Buffer{}
+	err = jpeg.Encode(&out, Orient(img, orientation), &jpeg.Options{Quality: jpegQuality})
+
+	return out.Bytes(), err
+}
+
+func reorientPNG(data []byte, orientation int) ([]byte, error) {
+	if _, _, err := imaging.DecodeConfig(data); err != nil {
+		return nil, err
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	out := bytes.Buffer{}
+	err = png.Encode(&out, Orient(img, orientation))
+
+	return out.Bytes(), err
+}
+
+// stripJPEG keeps the JFIF header, the ICC profile and the Adobe segment,
+// which decoders need to get the colors right, and drops every other
+// application segment and comment.
+func stripJPEG(data []byte) ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+
+	sos, err := walkJPEG(data, func(marker byte, payload, raw []byte) bool {
+		keep := true
+
+		switch {
+		case marker == 0xe2:
+			keep = bytes.HasPrefix(payload, []byte("ICC_PROFILE\x00"))
+		case marker == 0xe0, marker == 0xee:
+			keep = true
+		case marker >= 0xe1 && marker <= 0xef, marker == 0xfe:
+			keep = false
+		}
+
+		if keep {
+			out.Write(raw)
+		}
+
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	out.Write(data[sos:])
+
+	return out.Bytes(), nil
+}
+
+// Textual, EXIF and timestamp chunks of a PNG image.
+var pngMetadataChunks = map[string]bool{"tEXt": true, "zTXt": true, "iTXt": true, "eXIf": true, "tIME": true}
+
+func stripPNG(data []byte) ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+
+	err := walkPNG(data, func(kind string, payload, raw []byte) bool {
+		if !pngMetadataChunks[kind] {
+			out.Write(raw)
+		}
+
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// stripWebP drops the EXIF and XMP chunks and clears their flags in the
+// extended header.
+func stripWebP(data []byte) ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:12])
+
+	err := walkWebP(data, func(kind string, payload, raw []byte) bool {
+		switch kind {
+		case "EXIF", "XMP ":
+			return true
+		case "VP8X":
+			if len(payload) > 0 {
+				chunk := append([]byte{}, raw...)
+				chunk[8] &^= 0x08 | 0x04
+				out.Write(chunk)
+
+				return true
+			}
+		}
+
+		out.Write(raw)
+
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	stripped := out.Bytes()
+	binary.LittleEndian.PutUint32(stripped[4:8], uint32(len(stripped)-8))
+
+	return stripped, nil
+}
+
+// stripGIF drops comment extensions and application extensions other than
+// the ones controlling animation loops, which is where XMP is kept.
+func stripGIF(data []byte) ([]byte, error) {
+	if len(data) < 13 {
+		return nil, ErrMalformed
+	}
+
+	pos := 13
+	if data[10]&0x80 != 0 {
+		pos += 3 << ((data[10] & 0x07) + 1)
+	}
+
+	if pos > len(data) {
+		return nil, ErrMalformed
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:pos])
+
+	for pos < len(data) {
+		start := pos
+
+		switch data[pos] {
+		case 0x3b:
+			out.WriteByte(0x3b)
+			return out.Bytes(), nil
+		case 0x21:
+			if pos+2 > len(data) {
+				return nil, ErrMalformed
+			}
+
+			label := data[pos+1]
+			end, err := skipSubBlocks(data, pos+2)
+			if err != nil {
+				return nil, err
+			}
+
+			pos = end
+
+			if label == 0xfe {
+				continue
+			}
+
+			if label == 0xff {
+				id := ""
+				if start+14 <= len(data) && data[start+2] == 11 {
+					id = string(data[start+3 : start+14])
+				}
+
+				if id != "NETSCAPE2.0" && id != "ANIMEXTS1.0" {
+					continue
+				}
+			}
+		case 0x2c:
+			if pos+11 > len(data) {
+				return nil, ErrMalformed
+			}
+
+			pos += 10
+			if data[start+9]&0x80 != 0 {
+				pos += 3 << ((data[start+9] & 0x07) + 1)
+			}
+
+			// LZW minimum code size, then the image data.
+			end, err := skipSubBlocks(data, pos+1)
+			if err != nil {
+				return nil, err
+			}
+
+			pos = end
+		default:
+			return nil, ErrMalformed
+		}
+
+		out.Write(data[start:pos])
+	}
+
+	return nil, ErrMalformed
+}
+
+func skipSubBlocks(data []byte, pos int) (int, error) {
+	for pos < len(data) {
+		size := int(data[pos])
+		pos++
+
+		if size == 0 {
+			return pos, nil
+		}
+
+		pos += size
+	}
+
+	return 0, ErrMalformed
+}
@@ -0,0 +1,230 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+var (
+	iccProfile = []byte("ICC_PROFILE\x00\x01\x01fake profile")
+	xmpPacket  = []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta/>")
+)
+
+func sampleImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 40), G: uint8(y * 40), B: 128, A: 255})
+		}
+	}
+
+	return img
+}
+
+func jpegSegment(marker byte, payload []byte) []byte {
+	segment := []byte{0xff, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+
+	return append(segment, payload...)
+}
+
+// sampleJPEG is a 4x2 JPEG with EXIF, XMP, an ICC profile and a comment.
+func sampleJPEG(t *testing.T) []byte {
+	t.Helper()
+
+	encoded := bytes.Buffer{}
+	if err := jpeg.Encode(&encoded, sampleImage(4, 2), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte{0xff, 0xd8}
+	data = append(data, jpegSegment(0xe1, append(append([]byte{}, exifHeader...), sampleTIFF(binary.BigEndian)...))...)
+	data = append(data, jpegSegment(0xe1, xmpPacket)...)
+	data = append(data, jpegSegment(0xe2, iccProfile)...)
+	data = append(data, jpegSegment(0xfe, []byte("a comment"))...)
+
+	return append(data, encoded.Bytes()[2:]...)
+}
+
+func pngChunk(kind string, payload []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	chunk = append(chunk, kind...)
+	chunk = append(chunk, payload...)
+
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// samplePNG is a 4x2 PNG with eXIf, tEXt and iCCP chunks after its header.
+func samplePNG(t *testing.T) []byte {
+	t.Helper()
+
+	encoded := bytes.Buffer{}
+	if err := png.Encode(&encoded, sampleImage(4, 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The signature and IHDR come first.
+	header := len(pngSignature) + 12 + 13
+	data := append([]byte{}, encoded.Bytes()[:header]...)
+	data = append(data, pngChunk("iCCP", []byte("profile\x00\x00fake"))...)
+	data = append(data, pngChunk("eXIf", sampleTIFF(binary.LittleEndian))...)
+	data = append(data, pngChunk("tEXt", []byte("Comment\x00hello"))...)
+
+	return append(data, encoded.Bytes()[header:]...)
+}
+
+func webpChunk(kind string, payload []byte) []byte {
+	chunk := append([]byte(kind), binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))...)
+	chunk = append(chunk, payload...)
+	if len(payload)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+
+	return chunk
+}
+
+// sampleWebP is an extended WebP whose header flags an ICC profile, EXIF and
+// XMP. The image data is not a valid bitstream; Strip does not decode it.
+func sampleWebP() []byte {
+	// ICC, EXIF and XMP flags, then a 1x1 canvas.
+	vp8x := []byte{0x20 | 0x08 | 0x04, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+
+	data := []byte("RIFF\x00\x00\x00\x00WEBP")
+	data = append(data, webpChunk("VP8X", vp8x)...)
+	data = append(data, webpChunk("ICCP", []byte("fake profile"))...)
+	data = append(data, webpChunk("VP8L", []byte("\x2f\x00\x00\x00\x00"))...)
+	data = append(data, webpChunk("EXIF", sampleTIFF(binary.LittleEndian))...)
+	data = append(data, webpChunk("XMP ", []byte("<x:xmpmeta/>!"))...)
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(data)-8))
+
+	return data
+}
+
+func TestStripJPEG(t *testing.T) {
+	data := sampleJPEG(t)
+
+	stripped, err := Strip(data, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, removed := range [][]byte{exifHeader, xmpPacket, []byte("a comment")} {
+		if bytes.Contains(stripped, removed) {
+			t.Errorf("stripped JPEG still contains %q", removed)
+		}
+	}
+
+	if !bytes.Contains(stripped, iccProfile) {
+		t.Error("stripped JPEG lost its ICC profile")
+	}
+
+	if _, err := Parse(stripped); err != ErrNoMetadata {
+		t.Errorf("parse stripped JPEG: error = %v, want ErrNoMetadata", err)
+	}
+
+	original, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(stripped))
+	if err != nil {
+		t.Fatalf("decode stripped JPEG: %s", err)
+	}
+
+	// Stripping copies the image data, so the pixels stay the same.
+	if img.At(3, 1) != original.At(3, 1) {
+		t.Errorf("pixel changed from %v to %v", original.At(3, 1), img.At(3, 1))
+	}
+
+	turned, err := Strip(data, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err = jpeg.Decode(bytes.NewReader(turned))
+	if err != nil {
+		t.Fatalf("decode turned JPEG: %s", err)
+	}
+
+	if img.Bounds().Dx() != 2 || img.Bounds().Dy() != 4 || bytes.Contains(turned, exifHeader) {
+		t.Errorf("turned JPEG is %v", img.Bounds())
+	}
+}
+
+func TestStripPNG(t *testing.T) {
+	data := samplePNG(t)
+
+	stripped, err := Strip(data, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, removed := range []string{"eXIf", "tEXt"} {
+		if bytes.Contains(stripped, []byte(removed)) {
+			t.Errorf("stripped PNG still has a %s chunk", removed)
+		}
+	}
+
+	if !bytes.Contains(stripped, pngChunk("iCCP", []byte("profile\x00\x00fake"))) {
+		t.Error("stripped PNG lost its ICC profile")
+	}
+
+	if _, err := png.Decode(bytes.NewReader(stripped)); err != nil {
+		t.Fatalf("decode stripped PNG: %s", err)
+	}
+
+	if _, err := Parse(stripped); err != ErrNoMetadata {
+		t.Errorf("parse stripped PNG: error = %v, want ErrNoMetadata", err)
+	}
+}
+
+func TestStripWebP(t *testing.T) {
+	data := sampleWebP()
+
+	meta, err := Parse(data)
+	if err != nil || meta.Orientation != 6 {
+		t.Fatalf("parse WebP: %+v, %v", meta, err)
+	}
+
+	stripped, err := Strip(data, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := []string{}
+	if err := walkWebP(stripped, func(kind string, payload, raw []byte) bool {
+		kinds = append(kinds, kind)
+		if kind == "VP8X" && payload[0] != 0x20 {
+			t.Errorf("VP8X flags = %#x, want only the ICC flag", payload[0])
+		}
+
+		return true
+	}); err != nil {
+		t.Fatalf("walk stripped WebP: %s", err)
+	}
+
+	if got := strings.Join(kinds, " "); got != "VP8X ICCP VP8L" {
+		t.Errorf("chunks = %q, want VP8X ICCP VP8L", got)
+	}
+
+	if size := binary.LittleEndian.Uint32(stripped[4:8]); int(size) != len(stripped)-8 {
+		t.Errorf("RIFF size = %d, want %d", size, len(stripped)-8)
+	}
+}
+
+func TestStripOtherData(t *testing.T) {
+	data := []byte("not an image")
+
+	stripped, err := Strip(data, 6)
+	if err != nil || !bytes.Equal(stripped, data) {
+		t.Fatalf("Strip = %q, %v", stripped, err)
+	}
+}
@@ -1,18 +1,39 @@
 package helpers
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"strings"
 
-func HashPassword(p string) string {
-	salt := 8
-	password := []byte(p)
-	hash, _ := bcrypt.GenerateFromPassword(password, salt)
+	"golang.org/x/crypto/bcrypt"
+)
 
-	return string(hash)
+type BcryptHasher struct {
+	Cost int
 }
 
-func CheckPasswordHash(h, p []byte) bool {
-	hash, pass := []byte(h), []byte(p)
-	err := bcrypt.CompareHashAndPassword(hash, pass)
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
 
-	return err == nil
+	return string(hash), nil
+}
+
+func (h BcryptHasher) Verify(hash, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (h BcryptHasher) Identifies(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (h BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+
+	return err != nil || cost < h.Cost
 }
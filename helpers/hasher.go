@@ -0,0 +1,85 @@
+package helpers
+
+import (
+	"log"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher hashes passwords in a self-describing format so hashes made
+// with older algorithms or parameters can still be verified and upgraded.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(hash, password string) (bool, error)
+	// Identifies reports whether hash was produced by this algorithm.
+	Identifies(hash string) bool
+	// NeedsRehash reports whether hash is weaker than what Hash produces now.
+	NeedsRehash(hash string) bool
+}
+
+var (
+	hasher     PasswordHasher
+	hashers    []PasswordHasher
+	hasherOnce sync.Once
+)
+
+// PASSWORD_HASHER selects "bcrypt" (default, cost BCRYPT_COST) or "argon2id"
+// (ARGON2_MEMORY KiB, ARGON2_ITERATIONS, ARGON2_PARALLELISM) for new hashes.
+// Both algorithms are always accepted when verifying.
+func getHasher() PasswordHasher {
+	hasherOnce.Do(func() {
+		bcryptHasher := BcryptHasher{Cost: GetEnvInt("BCRYPT_COST", bcrypt.DefaultCost)}
+		argon2Hasher := Argon2idHasher{
+			Memory:      uint32(GetEnvInt("ARGON2_MEMORY", 64*1024)),
+			Iterations:  uint32(GetEnvInt("ARGON2_ITERATIONS", 3)),
+			Parallelism: uint8(GetEnvInt("ARGON2_PARALLELISM", 2)),
+			SaltLength:  16,
+			KeyLength:   32,
+		}
+
+		if bcryptHasher.Cost < bcrypt.MinCost || bcryptHasher.Cost > bcrypt.MaxCost {
+			log.Fatalf("BCRYPT_COST must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+
+		switch GetEnv("PASSWORD_HASHER", "bcrypt") {
+		case "bcrypt":
+			hasher = bcryptHasher
+		case "argon2id":
+			hasher = argon2Hasher
+		default:
+			log.Fatalf("Unknown PASSWORD_HASHER %q", GetEnv("PASSWORD_HASHER", ""))
+		}
+
+		hashers = []PasswordHasher{bcryptHasher, argon2Hasher}
+	})
+
+	return hasher
+}
+
+func HashPassword(p string) (string, error) {
+	return getHasher().Hash(p)
+}
+
+func CheckPasswordHash(h, p []byte) bool {
+	getHasher()
+
+	for _, candidate := range hashers {
+		if candidate.Identifies(string(h)) {
+			ok, err := candidate.Verify(string(h), string(p))
+
+			return err == nil && ok
+		}
+	}
+
+	return false
+}
+
+// PasswordNeedsRehash reports whether a stored hash uses another algorithm
+// than the configured one or weaker parameters, so it should be replaced the
+// next time the plaintext password is known.
+func PasswordNeedsRehash(h string) bool {
+	current := getHasher()
+
+	return !current.Identifies(h) || current.NeedsRehash(h)
+}
@@ -0,0 +1,62 @@
+package helpers
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A hashtag starts after the beginning of the text or a character that cannot
+// be part of a word or URL, so "a#b" and "example.com/#top" are not tags.
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_/&#])#([\p{L}\p{N}_]+)`)
+
+const maxHashtagLength = 100
+
+// ParseHashtags returns the distinct hashtags of text, lower cased and without
+// the leading #, in order of appearance. Tags made only of digits or
+// underscores, such as "#1", are ignored.
+func ParseHashtags(text string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+
+	for _, match := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+		tag := NormalizeHashtag(match[1])
+
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// NormalizeHashtag lower cases a tag and strips a leading #. It returns ""
+// for strings that are not valid tags.
+func NormalizeHashtag(tag string) string {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+
+	if tag == "" || utf8.RuneCountInString(tag) > maxHashtagLength {
+		return ""
+	}
+
+	hasLetter := false
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return ""
+		}
+
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		}
+	}
+
+	if !hasLetter {
+		return ""
+	}
+
+	return tag
+}
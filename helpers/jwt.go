@@ -3,27 +3,84 @@ package helpers
 import (
 	"errors"
 	"log"
-	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
-func GenerateToken(id uint, email string) string {
+func AccessTokenTTL() time.Duration {
+	return GetEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute)
+}
+
+func RefreshTokenTTL() time.Duration {
+	return GetEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+}
+
+func tokenIssuer() string {
+	return GetEnv("JWT_ISSUER", "final-project")
+}
+
+// Token types kept in the "typ" claim. Only access tokens are accepted by
+// VerifyToken; MFA tokens can only be exchanged through the MFA login.
+const (
+	TokenTypeAccess = "access"
+	TokenTypeMFA    = "mfa"
+)
+
+// GenerateToken signs an access token. sessionID is the login session the
+// token belongs to, so terminating the session also rejects the token.
+func GenerateToken(id uint, email, role, sessionID string) string {
 	errs := godotenv.Load(".env")
 	if errs != nil {
 		log.Fatalf("Some error occured. Err: %s", errs)
 	}
 
-	claims := jwt.MapClaims{
+	return signToken(jwt.MapClaims{
+		"typ":   TokenTypeAccess,
 		"id":    id,
 		"email": email,
+		"role":  role,
+		"sid":   sessionID,
+	}, AccessTokenTTL())
+}
+
+// GenerateMFAToken issues the short-lived token returned by a password login
+// when the user still has to provide a second factor.
+func GenerateMFAToken(id uint) string {
+	return signToken(jwt.MapClaims{
+		"typ": TokenTypeMFA,
+		"id":  id,
+	}, MFATokenTTL())
+}
+
+func MFATokenTTL() time.Duration {
+	return GetEnvDuration("MFA_TOKEN_TTL", 5*time.Minute)
+}
+
+func signToken(claims jwt.MapClaims, ttl time.Duration) string {
+	jti, err := GenerateRandomToken(16)
+	if err != nil {
+		panic("Error while generating token id")
 	}
 
-	parseToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := parseToken.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	now := time.Now()
+	id, _ := claims["id"].(uint)
+	claims["jti"] = jti
+	claims["sub"] = strconv.FormatUint(uint64(id), 10)
+	claims["iss"] = tokenIssuer()
+	claims["iat"] = now.Unix()
+	claims["iat_ms"] = now.UnixMilli()
+	claims["nbf"] = now.Unix()
+	claims["exp"] = now.Add(ttl).Unix()
+
+	key := getKeySet().signingKey()
+	parseToken := jwt.NewWithClaims(key.Method, claims)
+	parseToken.Header["kid"] = key.Kid
+	signedToken, err := parseToken.SignedString(key.Private)
 
 	if err != nil {
 		panic("Error while signing token")
@@ -35,30 +92,70 @@ func GenerateToken(id uint, email string) string {
 func VerifyToken(c *gin.Context) (interface{}, error) {
 	errResponse := errors.New("wrong token")
 	headerToken := c.Request.Header.Get("Authorization")
-	bearer := strings.HasPrefix(headerToken, "Bearer")
+	bearer := strings.HasPrefix(headerToken, "Bearer ")
 
 	if !bearer {
 		return nil, errResponse
 	}
 
-	jwtString := strings.Split(headerToken, " ")[1]
+	jwtString := strings.TrimSpace(strings.TrimPrefix(headerToken, "Bearer "))
+
+	return ParseToken(jwtString, TokenTypeAccess)
+}
 
-	token, err := jwt.Parse(jwtString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+// ParseToken validates a signed token string and makes sure it is of the expected type.
+func ParseToken(tokenString, tokenType string) (jwt.MapClaims, error) {
+	errResponse := errors.New("wrong token")
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		set := getKeySet()
+		kid, _ := token.Header["kid"].(string)
+
+		// Tokens signed before key ids were introduced carry no kid.
+		if kid == "" {
+			kid = hmacKid
+		}
+
+		key := set.verificationKey(kid)
+
+		// The key decides the algorithm, never the token header.
+		if key == nil || token.Method.Alg() != key.Method.Alg() {
 			return nil, errResponse
 		}
 
-		return []byte(os.Getenv("JWT_SECRET")), nil
+		return key.Public, nil
 	})
 
 	if err != nil {
+		if validationErr, ok := err.(*jwt.ValidationError); ok && validationErr.Errors&jwt.ValidationErrorExpired != 0 {
+			return nil, errors.New("token has expired")
+		}
+
 		return nil, errResponse
 	}
 
-	if _, ok := token.Claims.(jwt.MapClaims); !ok && !token.Valid {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
 		return nil, errResponse
 	}
 
-	return token.Claims, nil
+	// Tokens without an expiry or id predate revocable tokens and are no longer accepted.
+	if _, ok := claims["exp"]; !ok || !claims.VerifyIssuer(tokenIssuer(), true) {
+		return nil, errResponse
+	}
+
+	if jti, ok := claims["jti"].(string); !ok || jti == "" {
+		return nil, errResponse
+	}
+
+	typ, _ := claims["typ"].(string)
+	if typ == "" {
+		typ = TokenTypeAccess
+	}
+
+	if typ != tokenType {
+		return nil, errResponse
+	}
 
+	return claims, nil
 }
@@ -0,0 +1,369 @@
+package helpers
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SigningKey is one entry of the key set. Private is nil for keys that can
+// only verify, e.g. "<kid>.pub.pem" files published by another instance.
+type SigningKey struct {
+	Kid       string
+	Method    jwt.SigningMethod
+	Private   crypto.PrivateKey
+	Public    crypto.PublicKey
+	CreatedAt time.Time
+}
+
+type keySet struct {
+	mu     sync.RWMutex
+	method jwt.SigningMethod
+	dir    string
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+const hmacKid = "hs256"
+
+var (
+	keys         *keySet
+	keysOnce     sync.Once
+	rotationOnce sync.Once
+)
+
+// JWT_ALG selects HS256 (default, signed with JWT_SECRET), RS256, ES256 or
+// EdDSA. Asymmetric keys are PEM files in JWT_KEYS_DIR named "<kid>.pem";
+// the newest one signs unless JWT_ACTIVE_KID says otherwise, and all of them
+// verify.
+func getKeySet() *keySet {
+	keysOnce.Do(func() {
+		set, err := loadKeySet()
+		if err != nil {
+			log.Fatalf("Failed to load JWT keys. Err: %s", err)
+		}
+
+		keys = set
+	})
+
+	return keys
+}
+
+func loadKeySet() (*keySet, error) {
+	alg := GetEnv("JWT_ALG", "HS256")
+	method := jwt.GetSigningMethod(alg)
+
+	if method == nil || strings.HasPrefix(alg, "HS") && alg != "HS256" {
+		return nil, fmt.Errorf("unsupported JWT_ALG %q", alg)
+	}
+
+	set := &keySet{method: method, dir: GetEnv("JWT_KEYS_DIR", ""), keys: map[string]*SigningKey{}}
+
+	if alg == "HS256" {
+		key := &SigningKey{Kid: hmacKid, Method: method, Private: []byte(os.Getenv("JWT_SECRET")), Public: []byte(os.Getenv("JWT_SECRET"))}
+		set.active = key
+		set.keys[key.Kid] = key
+
+		return set, nil
+	}
+
+	if set.dir == "" {
+		return nil, fmt.Errorf("JWT_KEYS_DIR is required for %s", alg)
+	}
+
+	if err := set.reload(); err != nil {
+		return nil, err
+	}
+
+	if set.active == nil {
+		if _, err := set.rotate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return set, nil
+}
+
+// reload reads every PEM file in the key directory. Keys for a different
+// algorithm than JWT_ALG are ignored.
+func (s *keySet) reload() error {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	loaded := map[string]*SigningKey{}
+	var active *SigningKey
+
+	for _, file := range files {
+		key, err := readKeyFile(file)
+		if err != nil {
+			log.Printf("Skipping JWT key %s: %s", file, err)
+			continue
+		}
+
+		if key.Method.Alg() != s.method.Alg() {
+			continue
+		}
+
+		if existing, ok := loaded[key.Kid]; ok && existing.Private != nil {
+			continue
+		}
+
+		loaded[key.Kid] = key
+	}
+
+	if kid := GetEnv("JWT_ACTIVE_KID", ""); kid != "" {
+		active = loaded[kid]
+		if active == nil || active.Private == nil {
+			return fmt.Errorf("JWT_ACTIVE_KID %q has no private key in %s", kid, s.dir)
+		}
+	} else {
+		for _, key := range loaded {
+			if key.Private != nil && (active == nil || key.CreatedAt.After(active.CreatedAt)) {
+				active = key
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.keys = loaded
+	s.active = active
+	s.mu.Unlock()
+
+	return nil
+}
+
+// rotate generates a new private key, stores it in the key directory and
+// makes it the signing key. Older keys keep verifying tokens.
+func (s *keySet) rotate() (*SigningKey, error) {
+	var private crypto.Signer
+	var err error
+
+	switch s.method.Alg() {
+	case "RS256":
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		err = fmt.Errorf("cannot generate keys for %s", s.method.Alg())
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix, err := GenerateRandomToken(4)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	kid := now.UTC().Format("20060102T150405Z") + "-" + suffix
+	file := filepath.Join(s.dir, kid+".pem")
+
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{Kid: kid, Method: s.method, Private: private, Public: private.Public(), CreatedAt: now}
+
+	s.mu.Lock()
+	s.keys[kid] = key
+	s.active = key
+	s.mu.Unlock()
+
+	return key, nil
+}
+
+// prune forgets, and deletes the files of, keys that were replaced longer
+// than retention ago, by which time every token they signed has expired.
+func (s *keySet) prune(retention time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ordered := make([]*SigningKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		ordered = append(ordered, key)
+	}
+
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].CreatedAt.Before(ordered[j].CreatedAt)
+	})
+
+	for i := 0; i < len(ordered)-1; i++ {
+		key := ordered[i]
+		replacedAt := ordered[i+1].CreatedAt
+
+		if key == s.active || time.Since(replacedAt) < retention {
+			continue
+		}
+
+		delete(s.keys, key.Kid)
+
+		for _, name := range []string{key.Kid + ".pem", key.Kid + ".pub.pem"} {
+			if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Printf("Failed to remove JWT key %s: %s", name, err)
+			}
+		}
+	}
+}
+
+func (s *keySet) signingKey() *SigningKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.active
+}
+
+func (s *keySet) verificationKey(kid string) *SigningKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.keys[kid]
+}
+
+// VerificationKeys returns every key tokens may currently be signed with.
+func VerificationKeys() []*SigningKey {
+	set := getKeySet()
+	set.mu.RLock()
+	defer set.mu.RUnlock()
+
+	list := make([]*SigningKey, 0, len(set.keys))
+	for _, key := range set.keys {
+		list = append(list, key)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+
+	return list
+}
+
+// StartKeyRotation reloads the key directory every JWT_KEYS_RELOAD_INTERVAL so
+// keys created by other instances are trusted, and generates a new signing key
+// once the active one is older than JWT_KEY_ROTATION_INTERVAL (disabled when
+// unset). Replaced keys are kept for JWT_KEY_RETENTION.
+func StartKeyRotation() {
+	set := getKeySet()
+	if set.dir == "" {
+		return
+	}
+
+	rotationOnce.Do(func() {
+		rotateEvery := GetEnvDuration("JWT_KEY_ROTATION_INTERVAL", 0)
+		retention := GetEnvDuration("JWT_KEY_RETENTION", AccessTokenTTL()+time.Hour)
+		ticker := time.NewTicker(GetEnvDuration("JWT_KEYS_RELOAD_INTERVAL", time.Minute))
+
+		go func() {
+			defer ticker.Stop()
+
+			for range ticker.C {
+				if err := set.reload(); err != nil {
+					log.Println("Failed to reload JWT keys: ", err)
+					continue
+				}
+
+				if active := set.signingKey(); rotateEvery > 0 && GetEnv("JWT_ACTIVE_KID", "") == "" && (active == nil || time.Since(active.CreatedAt) >= rotateEvery) {
+					if key, err := set.rotate(); err != nil {
+						log.Println("Failed to rotate JWT key: ", err)
+					} else {
+						log.Println("Rotated JWT signing key to ", key.Kid)
+					}
+				}
+
+				if rotateEvery > 0 {
+					set.prune(retention)
+				}
+			}
+		}()
+	})
+}
+
+func readKeyFile(file string) (*SigningKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	name := filepath.Base(file)
+	key := &SigningKey{Kid: strings.TrimSuffix(strings.TrimSuffix(name, ".pem"), ".pub"), CreatedAt: info.ModTime()}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		key.Private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key.Private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key.Private, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key.Public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key.Public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		err = fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if signer, ok := key.Private.(crypto.Signer); ok {
+		key.Public = signer.Public()
+	}
+
+	switch public := key.Public.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		if public.Curve != elliptic.P256() {
+			return nil, errors.New("only P-256 EC keys are supported")
+		}
+
+		key.Method = jwt.SigningMethodES256
+	case ed25519.PublicKey:
+		key.Method = EdDSA
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+
+	return key, nil
+}
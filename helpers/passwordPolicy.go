@@ -0,0 +1,52 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ValidatePassword enforces the password policy: at least PASSWORD_MIN_LENGTH
+// characters (default 8), no more than bcrypt's 72 bytes, at least one letter
+// and one digit, and not containing any of the given identifiers such as the
+// username or the local part of the email address.
+func ValidatePassword(password string, identifiers ...string) error {
+	minLength := GetEnvInt("PASSWORD_MIN_LENGTH", 8)
+
+	if len([]rune(password)) < minLength {
+		return fmt.Errorf("Password must be at least %d characters", minLength)
+	}
+
+	if len(password) > 72 {
+		return errors.New("Password must be at most 72 bytes")
+	}
+
+	hasLetter, hasDigit := false, false
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+
+	if !hasLetter || !hasDigit {
+		return errors.New("Password must contain at least one letter and one digit")
+	}
+
+	lower := strings.ToLower(password)
+	for _, identifier := range identifiers {
+		identifier = strings.ToLower(strings.TrimSpace(identifier))
+		if at := strings.Index(identifier, "@"); at >= 0 {
+			identifier = identifier[:at]
+		}
+
+		if len(identifier) >= 3 && strings.Contains(lower, identifier) {
+			return errors.New("Password must not contain your username or email")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package helpers
+
+import "strings"
+
+const PersonalAccessTokenPrefix = "pat_"
+
+// ScopeResources are the resources a personal access token can be scoped to,
+// each with a "read" and a "write" level; write also grants read.
+var ScopeResources = []string{"users", "photos", "comments", "socialmedias"}
+
+func ValidScope(scope string) bool {
+	resource, level, ok := strings.Cut(scope, ":")
+	if !ok || (level != "read" && level != "write") {
+		return false
+	}
+
+	for _, r := range ScopeResources {
+		if r == resource {
+			return true
+		}
+	}
+
+	return false
+}
+
+func HasScope(scopes []string, resource string, write bool) bool {
+	for _, scope := range scopes {
+		if scope == resource+":write" || (!write && scope == resource+":read") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,47 @@
+// Package imaging holds what the packages decoding uploads share: the pixel
+// limit checked before decoding and the box sampling used to shrink images.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// MaxPixels is the largest width times height decoded from an upload. The
+// header of a small file can claim any size, and decoding allocates it all.
+const MaxPixels = 50_000_000
+
+var ErrTooManyPixels = errors.New("image has too many pixels")
+
+// DecodeConfig reads the format and size from the header of data, without
+// decoding the pixels, and fails with ErrTooManyPixels above MaxPixels.
+func DecodeConfig(data []byte) (image.Config, string, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return config, format, err
+	}
+
+	if config.Width*config.Height > MaxPixels {
+		return config, format, ErrTooManyPixels
+	}
+
+	return config, format, nil
+}
+
+// Span returns the source pixels [from, to) covered by target pixel i of n
+// when shrinking a row or column of size pixels. Every target pixel covers at
+// least one source pixel.
+func Span(i, n, size int) (int, int) {
+	from := i * size / n
+	to := (i + 1) * size / n
+
+	if to <= from {
+		to = min(from+1, size)
+	}
+
+	return from, to
+}
@@ -0,0 +1,329 @@
+package listquery
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"final-project/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Sort keys every list supports. Rows are always ordered by the key and then
+// by id, so cursors stay stable when several rows share a timestamp.
+const (
+	SortCreatedAt = "created_at"
+	SortUpdatedAt = "updated_at"
+	SortID        = "id"
+)
+
+const (
+	directionNext = "next"
+	directionPrev = "prev"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("sort must be one of created_at, updated_at or id, optionally prefixed with - for descending order")
+)
+
+// Options describes one list endpoint. Table qualifies the sort columns,
+// Filters maps accepted id filters such as "user_id" to their column.
+type Options struct {
+	Table        string
+	Filters      map[string]string
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// Key is the position of a row in any of the supported orders.
+type Key struct {
+	ID        uint
+	CreatedAt *time.Time
+	UpdatedAt *time.Time
+}
+
+// Query is a parsed list request: ?limit=&cursor=&sort=&created_after=&created_before=
+// plus the id filters of the endpoint.
+type Query struct {
+	Limit   int
+	Sort    string
+	Desc    bool
+	opts    Options
+	cursor  *cursor
+	filters map[string]uint64
+	after   *time.Time
+	before  *time.Time
+}
+
+// Pagination is returned next to the data of every list. A nil cursor means
+// there is nothing more in that direction.
+type Pagination struct {
+	Limit      int     `json:"limit"`
+	Sort       string  `json:"sort"`
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor"`
+}
+
+type cursor struct {
+	Sort      string `json:"s"`
+	Desc      bool   `json:"d"`
+	Direction string `json:"dir"`
+	Value     string `json:"v"`
+	ID        uint   `json:"id"`
+}
+
+// Parse reads the list parameters of the request. Errors are meant to be
+// reported to the client as a bad request.
+func Parse(c *gin.Context, opts Options) (*Query, error) {
+	if opts.DefaultLimit == 0 {
+		opts.DefaultLimit = 20
+	}
+
+	if opts.MaxLimit == 0 {
+		opts.MaxLimit = 100
+	}
+
+	q := &Query{Limit: opts.DefaultLimit, Sort: SortCreatedAt, Desc: true, opts: opts, filters: map[string]uint64{}}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return nil, errors.New("limit must be a positive number")
+		}
+
+		if limit > opts.MaxLimit {
+			limit = opts.MaxLimit
+		}
+
+		q.Limit = limit
+	}
+
+	if value := c.Query("sort"); value != "" {
+		q.Desc = strings.HasPrefix(value, "-")
+		q.Sort = strings.TrimPrefix(value, "-")
+
+		if q.Sort != SortCreatedAt && q.Sort != SortUpdatedAt && q.Sort != SortID {
+			return nil, ErrInvalidSort
+		}
+	}
+
+	if value := c.Query("cursor"); value != "" {
+		cur, err := decodeCursor(value)
+		if err != nil {
+			return nil, err
+		}
+
+		// A cursor only makes sense in the order it was issued for.
+		if cur.Sort != q.Sort || cur.Desc != q.Desc {
+			return nil, errors.New("cursor does not match the requested sort")
+		}
+
+		q.cursor = cur
+	}
+
+	for param := range opts.Filters {
+		if value := c.Query(param); value != "" {
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a number", param)
+			}
+
+			q.filters[param] = id
+		}
+	}
+
+	var err error
+	if q.after, err = parseTime(c, "created_after"); err != nil {
+		return nil, err
+	}
+
+	if q.before, err = parseTime(c, "created_before"); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// SortParam is the sort in the format accepted by the sort parameter.
+func (q *Query) SortParam() string {
+	if q.Desc {
+		return "-" + q.Sort
+	}
+
+	return q.Sort
+}
+
+// Apply adds the filters, the keyset condition, the order and the limit to
+// db. One row more than the limit is fetched to know whether another page
+// follows.
+func (q *Query) Apply(db *gorm.DB) *gorm.DB {
+	for param, id := range q.filters {
+		db = db.Where(q.opts.Filters[param]+" = ?", id)
+	}
+
+	if q.after != nil {
+		db = db.Where(q.column(SortCreatedAt)+" >= ?", *q.after)
+	}
+
+	if q.before != nil {
+		db = db.Where(q.column(SortCreatedAt)+" < ?", *q.before)
+	}
+
+	// Walking backwards flips both the comparison and the order; Page
+	// restores the requested order afterwards.
+	desc := q.Desc != q.backwards()
+
+	if q.cursor != nil {
+		operator := ">"
+		if desc {
+			operator = "<"
+		}
+
+		if q.Sort == SortID {
+			db = db.Where(fmt.Sprintf("%s %s ?", q.column(SortID), operator), q.cursor.ID)
+		} else {
+			value, _ := time.Parse(time.RFC3339Nano, q.cursor.Value)
+			db = db.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", q.column(q.Sort), q.column(SortID), operator), value, q.cursor.ID)
+		}
+	}
+
+	order := "asc"
+	if desc {
+		order = "desc"
+	}
+
+	if q.Sort == SortID {
+		db = db.Order(fmt.Sprintf("%s %s", q.column(SortID), order))
+	} else {
+		db = db.Order(fmt.Sprintf("%s %s, %s %s", q.column(q.Sort), order, q.column(SortID), order))
+	}
+
+	return db.Limit(q.Limit + 1)
+}
+
+// Page trims rows fetched with Apply to the requested limit, puts them in the
+// requested order and builds the cursors of the neighbouring pages.
+func Page[T any](q *Query, rows []T, key func(T) Key) ([]T, Pagination) {
+	more := len(rows) > q.Limit
+	if more {
+		rows = rows[:q.Limit]
+	}
+
+	if q.backwards() {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	pagination := Pagination{Limit: q.Limit, Sort: q.SortParam()}
+
+	if len(rows) == 0 {
+		return rows, pagination
+	}
+
+	// Coming from a later page there is always a next page, and coming from
+	// an earlier one there is always a previous page.
+	hasNext := more
+	hasPrev := q.cursor != nil
+
+	if q.backwards() {
+		hasNext = true
+		hasPrev = more
+	}
+
+	if hasNext {
+		next := q.encode(directionNext, key(rows[len(rows)-1]))
+		pagination.NextCursor = &next
+	}
+
+	if hasPrev {
+		prev := q.encode(directionPrev, key(rows[0]))
+		pagination.PrevCursor = &prev
+	}
+
+	return rows, pagination
+}
+
+func (q *Query) backwards() bool {
+	return q.cursor != nil && q.cursor.Direction == directionPrev
+}
+
+func (q *Query) column(name string) string {
+	if q.opts.Table == "" {
+		return name
+	}
+
+	return q.opts.Table + "." + name
+}
+
+func (q *Query) encode(direction string, key Key) string {
+	cur := cursor{Sort: q.Sort, Desc: q.Desc, Direction: direction, ID: key.ID}
+
+	switch q.Sort {
+	case SortCreatedAt:
+		cur.Value = formatTime(key.CreatedAt)
+	case SortUpdatedAt:
+		cur.Value = formatTime(key.UpdatedAt)
+	}
+
+	raw, _ := json.Marshal(cur)
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(value string) (*cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	cur := &cursor{}
+	if err := json.Unmarshal(raw, cur); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if cur.Direction != directionNext && cur.Direction != directionPrev {
+		return nil, ErrInvalidCursor
+	}
+
+	if cur.Sort != SortID {
+		if _, err := time.Parse(time.RFC3339Nano, cur.Value); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return cur, nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return time.Time{}.Format(time.RFC3339Nano)
+	}
+
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseTime(c *gin.Context, param string) (*time.Time, error) {
+	value := c.Query(param)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", param)
+	}
+
+	return &t, nil
+}
+
+// KeyOf returns the key of a row embedding models.GormModel.
+func KeyOf(m models.GormModel) Key {
+	return Key{ID: m.ID, CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt}
+}
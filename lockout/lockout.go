@@ -0,0 +1,183 @@
+package lockout
+
+import (
+	"final-project/database"
+	"final-project/helpers"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Policy describes how failures for one kind of key are throttled. The first
+// FreeAttempts failures are not delayed, after that every failure doubles the
+// wait starting from BaseDelay up to MaxDelay, and Threshold failures within
+// Window lock the key for LockoutDuration.
+type Policy struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	Threshold       int
+	LockoutDuration time.Duration
+	Window          time.Duration
+}
+
+type Guard struct {
+	Store   Store
+	Account Policy
+	IP      Policy
+}
+
+var (
+	guard *Guard
+	once  sync.Once
+)
+
+// GetGuard returns the guard configured from the environment. LOGIN_ATTEMPT_STORE
+// selects "memory" (default) or "postgres" for the counters.
+func GetGuard() *Guard {
+	once.Do(func() {
+		var store Store
+
+		switch helpers.GetEnv("LOGIN_ATTEMPT_STORE", "memory") {
+		case "postgres":
+			store = NewPostgresStore(database.GetDB())
+		case "memory":
+			store = NewMemoryStore()
+		default:
+			log.Fatalf("Unknown LOGIN_ATTEMPT_STORE %q", helpers.GetEnv("LOGIN_ATTEMPT_STORE", ""))
+		}
+
+		guard = &Guard{
+			Store: store,
+			Account: Policy{
+				FreeAttempts:    helpers.GetEnvInt("LOGIN_FREE_ATTEMPTS", 3),
+				BaseDelay:       helpers.GetEnvDuration("LOGIN_BACKOFF_BASE", time.Second),
+				MaxDelay:        helpers.GetEnvDuration("LOGIN_BACKOFF_MAX", 5*time.Minute),
+				Threshold:       helpers.GetEnvInt("LOGIN_LOCKOUT_THRESHOLD", 10),
+				LockoutDuration: helpers.GetEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+				Window:          helpers.GetEnvDuration("LOGIN_ATTEMPT_WINDOW", time.Hour),
+			},
+			IP: Policy{
+				FreeAttempts:    helpers.GetEnvInt("LOGIN_IP_FREE_ATTEMPTS", 10),
+				BaseDelay:       helpers.GetEnvDuration("LOGIN_BACKOFF_BASE", time.Second),
+				MaxDelay:        helpers.GetEnvDuration("LOGIN_BACKOFF_MAX", 5*time.Minute),
+				Threshold:       helpers.GetEnvInt("LOGIN_IP_LOCKOUT_THRESHOLD", 50),
+				LockoutDuration: helpers.GetEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+				Window:          helpers.GetEnvDuration("LOGIN_ATTEMPT_WINDOW", time.Hour),
+			},
+		}
+	})
+
+	return guard
+}
+
+func AccountKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check returns how long the caller has to wait before the next login attempt
+// for this email and IP is allowed; zero means the attempt may proceed.
+func (g *Guard) Check(email, ip string) (time.Duration, error) {
+	now := time.Now()
+
+	accountWait, err := g.wait(AccountKey(email), g.Account, now)
+	if err != nil {
+		return 0, err
+	}
+
+	ipWait, err := g.wait(IPKey(ip), g.IP, now)
+	if err != nil {
+		return 0, err
+	}
+
+	if ipWait > accountWait {
+		return ipWait, nil
+	}
+
+	return accountWait, nil
+}
+
+// Fail records a failed attempt for the email and IP and locks whichever key
+// reached its threshold.
+func (g *Guard) Fail(email, ip string) error {
+	now := time.Now()
+
+	if err := g.fail(AccountKey(email), g.Account, now); err != nil {
+		return err
+	}
+
+	return g.fail(IPKey(ip), g.IP, now)
+}
+
+// Succeed clears the account counters. The IP counters are left alone so one
+// valid account cannot be used to reset the limit for an attacking address.
+func (g *Guard) Succeed(email string) error {
+	return g.Store.Reset(AccountKey(email))
+}
+
+func (g *Guard) UnlockAccount(email string) error {
+	return g.Store.Reset(AccountKey(email))
+}
+
+func (g *Guard) UnlockIP(ip string) error {
+	return g.Store.Reset(IPKey(ip))
+}
+
+func (g *Guard) wait(key string, policy Policy, now time.Time) (time.Duration, error) {
+	attempt, err := g.Store.Get(key)
+	if err != nil {
+		return 0, err
+	}
+
+	until := attempt.LockedUntil
+	if now.Sub(attempt.LastFailure) <= policy.Window {
+		if backoff := attempt.LastFailure.Add(policy.backoff(attempt.Failures)); backoff.After(until) {
+			until = backoff
+		}
+	}
+
+	if until.After(now) {
+		return until.Sub(now), nil
+	}
+
+	return 0, nil
+}
+
+func (g *Guard) fail(key string, policy Policy, now time.Time) error {
+	attempt, err := g.Store.Increment(key, now, policy.Window)
+	if err != nil {
+		return err
+	}
+
+	if policy.Threshold > 0 && attempt.Failures >= policy.Threshold {
+		return g.Store.Lock(key, now.Add(policy.LockoutDuration))
+	}
+
+	return nil
+}
+
+func (p Policy) backoff(failures int) time.Duration {
+	extra := failures - p.FreeAttempts
+	if extra <= 0 {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < extra; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+
+	return delay
+}
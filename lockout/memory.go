@@ -0,0 +1,84 @@
+package lockout
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often Increment drops entries nobody has failed on
+// within the window, so one-off keys do not pile up forever.
+const sweepInterval = time.Minute
+
+type MemoryStore struct {
+	mu        sync.Mutex
+	attempts  map[string]Attempt
+	window    time.Duration
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{attempts: map[string]Attempt{}}
+}
+
+func (s *MemoryStore) Get(key string) (Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.attempts[key], nil
+}
+
+func (s *MemoryStore) Increment(key string, now time.Time, window time.Duration) (Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if window > s.window {
+		s.window = window
+	}
+
+	if now.Sub(s.lastSweep) > sweepInterval {
+		s.sweep(now)
+	}
+
+	attempt := s.attempts[key]
+	if now.Sub(attempt.LastFailure) > window {
+		attempt.Failures = 0
+	}
+
+	attempt.Failures++
+	attempt.LastFailure = now
+	s.attempts[key] = attempt
+
+	return attempt, nil
+}
+
+func (s *MemoryStore) Lock(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt := s.attempts[key]
+	attempt.LockedUntil = until
+	s.attempts[key] = attempt
+
+	return nil
+}
+
+func (s *MemoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+
+	return nil
+}
+
+// sweep removes entries whose window and lockout are both over; they would
+// start from zero on the next failure anyway. Callers hold s.mu.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, attempt := range s.attempts {
+		if now.Sub(attempt.LastFailure) > s.window && now.After(attempt.LockedUntil) {
+			delete(s.attempts, key)
+		}
+	}
+
+	s.lastSweep = now
+}
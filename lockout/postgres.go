@@ -0,0 +1,73 @@
+package lockout
+
+import (
+	"errors"
+	"final-project/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Get(key string) (Attempt, error) {
+	row := models.LoginAttempt{}
+
+	err := s.db.Where("key = ?", key).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Attempt{}, nil
+	}
+
+	if err != nil {
+		return Attempt{}, err
+	}
+
+	return toAttempt(row), nil
+}
+
+func (s *PostgresStore) Increment(key string, now time.Time, window time.Duration) (Attempt, error) {
+	row := models.LoginAttempt{Key: key, Failures: 1, LastFailureAt: now}
+
+	// A single upsert keeps concurrent failures from losing increments.
+	err := s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"failures":        gorm.Expr("CASE WHEN login_attempts.last_failure_at < ? THEN 1 ELSE login_attempts.failures + 1 END", now.Add(-window)),
+			"last_failure_at": now,
+		}),
+	}).Create(&row).Error
+
+	if err != nil {
+		return Attempt{}, err
+	}
+
+	return s.Get(key)
+}
+
+func (s *PostgresStore) Lock(key string, until time.Time) error {
+	return s.db.Model(&models.LoginAttempt{}).Where("key = ?", key).Update("locked_until", until).Error
+}
+
+func (s *PostgresStore) Reset(key string) error {
+	return s.db.Where("key = ?", key).Delete(&models.LoginAttempt{}).Error
+}
+
+func toAttempt(row models.LoginAttempt) Attempt {
+	attempt := Attempt{
+		Failures:    row.Failures,
+		LastFailure: row.LastFailureAt,
+	}
+
+	if row.LockedUntil != nil {
+		attempt.LockedUntil = *row.LockedUntil
+	}
+
+	return attempt
+}
@@ -0,0 +1,21 @@
+package lockout
+
+import "time"
+
+type Attempt struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// Store keeps failed login counters per key ("email:..." or "ip:...").
+// MemoryStore works for a single instance, PostgresStore shares the
+// counters between instances.
+type Store interface {
+	Get(key string) (Attempt, error)
+	// Increment records a failure at now, restarting the count when the
+	// previous failure is older than window.
+	Increment(key string, now time.Time, window time.Duration) (Attempt, error)
+	Lock(key string, until time.Time) error
+	Reset(key string) error
+}
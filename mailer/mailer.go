@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"final-project/helpers"
+	"log"
+	"sync"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers outgoing mail. SMTPSender is used in production and
+// LogSender writes messages to a file or the log for development and tests.
+type Sender interface {
+	Send(msg Message) error
+}
+
+var (
+	sender Sender
+	once   sync.Once
+)
+
+// GetSender returns the sender selected by MAIL_DRIVER ("smtp" or "log").
+func GetSender() Sender {
+	once.Do(func() {
+		if sender != nil {
+			return
+		}
+
+		switch helpers.GetEnv("MAIL_DRIVER", "log") {
+		case "smtp":
+			sender = NewSMTPSender(
+				helpers.GetEnv("SMTP_HOST", "localhost"),
+				helpers.GetEnv("SMTP_PORT", "587"),
+				helpers.GetEnv("SMTP_USERNAME", ""),
+				helpers.GetEnv("SMTP_PASSWORD", ""),
+				helpers.GetEnv("MAIL_FROM", "no-reply@localhost"),
+			)
+		case "log":
+			sender = NewLogSender(helpers.GetEnv("MAIL_LOG_PATH", ""))
+		default:
+			log.Fatalf("Unknown MAIL_DRIVER %q", helpers.GetEnv("MAIL_DRIVER", ""))
+		}
+	})
+
+	return sender
+}
+
+// SetSender replaces the configured sender, e.g. with a LogSender in tests.
+func SetSender(s Sender) {
+	once.Do(func() {})
+	sender = s
+}
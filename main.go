@@ -3,7 +3,12 @@ package main
 import (
 	"final-project/database"
 	_ "final-project/docs"
+	"final-project/helpers"
+	"final-project/policy"
+	"final-project/renditions"
+	"final-project/revocation"
 	"final-project/router"
+	"final-project/search"
 	"log"
 	"os"
 
@@ -26,6 +31,15 @@ func main() {
 		log.Fatalf("Some error occured. Err: %s", errs)
 	}
 	database.StartDB()
+	if err := policy.BootstrapAdmins(database.GetDB()); err != nil {
+		log.Println("Failed to bootstrap admins: ", err)
+	}
+	if err := search.Migrate(database.GetDB()); err != nil {
+		log.Println("Failed to set up full text search: ", err)
+	}
+	revocation.StartSync()
+	renditions.Start(database.GetDB())
+	helpers.StartKeyRotation()
 	r := router.StartApp()
 	var PORT = os.Getenv("PORT")
 	r.Run(":" + PORT)
@@ -1,24 +1,137 @@
 package middlewares
 
 import (
+	"final-project/database"
 	"final-project/helpers"
+	"final-project/models"
+	"final-project/revocation"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
 func Authentication() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if token, ok := personalAccessToken(c); ok {
+			authenticatePersonalAccessToken(c, token)
+			return
+		}
+
 		if userData, err := helpers.VerifyToken(c); err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error":   "Unauthorized",
 				"message": err.Error(),
 			})
 
+			return
+		} else if revocation.IsRevoked(userData.(jwt.MapClaims)) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error":   "Unauthorized",
+				"message": "token has been revoked",
+			})
+
 			return
 		} else {
+			touchSession(userData.(jwt.MapClaims))
 			c.Set("userData", userData)
 			c.Next()
 		}
 	}
 }
+
+// Terminated sessions are rejected by revocation.IsRevoked; lastSeen only
+// remembers when each session's last_seen_at was last written.
+var (
+	lastSeenMu    sync.Mutex
+	lastSeen      = map[string]time.Time{}
+	lastSeenSweep time.Time
+)
+
+// touchSession keeps last_seen_at roughly current without reading the
+// session on every request. Tokens signed before sessions were recorded have
+// no "sid".
+func touchSession(claims jwt.MapClaims) {
+	sid, _ := claims["sid"].(string)
+	if sid == "" {
+		return
+	}
+
+	now := time.Now()
+	interval := helpers.GetEnvDuration("SESSION_LAST_SEEN_INTERVAL", time.Minute)
+
+	lastSeenMu.Lock()
+	// Like last_used_at on personal access tokens, last_seen_at is written at most once per interval.
+	if now.Sub(lastSeen[sid]) <= interval {
+		lastSeenMu.Unlock()
+		return
+	}
+
+	lastSeen[sid] = now
+
+	// Sessions unused for a whole token lifetime need a refresh first, which
+	// goes through the database anyway.
+	if now.Sub(lastSeenSweep) > interval {
+		for key, seen := range lastSeen {
+			if now.Sub(seen) > helpers.AccessTokenTTL() {
+				delete(lastSeen, key)
+			}
+		}
+
+		lastSeenSweep = now
+	}
+	lastSeenMu.Unlock()
+
+	database.GetDB().Model(&models.Session{}).Where("family_id = ?", sid).UpdateColumn("last_seen_at", now)
+}
+
+func personalAccessToken(c *gin.Context) (string, bool) {
+	token := strings.TrimSpace(strings.TrimPrefix(c.Request.Header.Get("Authorization"), "Bearer "))
+
+	return token, strings.HasPrefix(token, helpers.PersonalAccessTokenPrefix)
+}
+
+// authenticatePersonalAccessToken sets the same "userData" claims a JWT would,
+// plus "pat_id" and "scopes" so Scope can restrict what the token may do.
+func authenticatePersonalAccessToken(c *gin.Context, token string) {
+	db := database.GetDB()
+	pat := models.PersonalAccessToken{}
+	user := models.User{}
+	now := time.Now()
+
+	err := db.Where("token_hash = ?", helpers.HashToken(token)).First(&pat).Error
+	if err != nil || pat.RevokedAt != nil || (pat.ExpiresAt != nil && now.After(*pat.ExpiresAt)) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "wrong token",
+		})
+
+		return
+	}
+
+	if err := db.Select("id", "email", "role").First(&user, pat.UserId).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "wrong token",
+		})
+
+		return
+	}
+
+	// last_used_at is only a hint, so it is written at most once per interval.
+	if pat.LastUsedAt == nil || now.Sub(*pat.LastUsedAt) > helpers.GetEnvDuration("PAT_LAST_USED_INTERVAL", time.Minute) {
+		db.Model(&pat).UpdateColumn("last_used_at", now)
+	}
+
+	c.Set("userData", jwt.MapClaims{
+		"id":     float64(user.ID),
+		"email":  user.Email,
+		"role":   user.Role,
+		"pat_id": float64(pat.ID),
+		"scopes": pat.ScopeList(),
+	})
+	c.Next()
+}
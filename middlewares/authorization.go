@@ -3,6 +3,7 @@ package middlewares
 import (
 	"final-project/database"
 	"final-project/models"
+	"final-project/policy"
 	"net/http"
 	"strconv"
 
@@ -74,8 +75,6 @@ func PhotoAuthorization() gin.HandlerFunc {
 			return
 		}
 
-		userData := c.MustGet("userData").(jwt.MapClaims)
-		userID := uint(userData["id"].(float64))
 		Photo := models.Photo{}
 
 		err = db.Select("user_id").First(&Photo, uint(photoId)).Error
@@ -89,14 +88,7 @@ func PhotoAuthorization() gin.HandlerFunc {
 			return
 		}
 
-		if Photo.UserId != userID {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error":   "Unauthorized",
-				"message": "You are not authorized to access this resource",
-			})
-
-			return
-		}
+		authorizeOwner(c, policy.ResourcePhoto, uint(photoId), Photo.UserId)
 	}
 }
 
@@ -114,8 +106,6 @@ func CommentAuthorization() gin.HandlerFunc {
 			return
 		}
 
-		userData := c.MustGet("userData").(jwt.MapClaims)
-		userID := uint(userData["id"].(float64))
 		Comment := models.Comment{}
 
 		err = db.Select("user_id").First(&Comment, uint(commentId)).Error
@@ -129,14 +119,7 @@ func CommentAuthorization() gin.HandlerFunc {
 			return
 		}
 
-		if Comment.UserId != userID {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error":   "Unauthorized",
-				"message": "You are not authorized to access this resource",
-			})
-
-			return
-		}
+		authorizeOwner(c, policy.ResourceComment, uint(commentId), Comment.UserId)
 	}
 }
 
@@ -154,8 +137,6 @@ func SocialMediaAuthorization() gin.HandlerFunc {
 			return
 		}
 
-		userData := c.MustGet("userData").(jwt.MapClaims)
-		userID := uint(userData["id"].(float64))
 		SocialMedia := models.SocialMedia{}
 
 		err = db.Select("user_id").First(&SocialMedia, uint(socialMediaId)).Error
@@ -168,10 +149,63 @@ func SocialMediaAuthorization() gin.HandlerFunc {
 			return
 		}
 
-		if SocialMedia.UserId != userID {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error":   "Unauthorized",
-				"message": "You are not authorized to access this resource",
+		authorizeOwner(c, policy.ResourceSocialMedia, uint(socialMediaId), SocialMedia.UserId)
+	}
+}
+
+func AlbumAuthorization() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := database.GetDB()
+		albumId, err := strconv.Atoi(c.Param("albumId"))
+
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "Bad Request",
+				"message": err.Error(),
+			})
+
+			return
+		}
+
+		Album := models.Album{}
+
+		err = db.Select("user_id").First(&Album, uint(albumId)).Error
+
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+				"error":   "Not Found",
+				"message": err.Error(),
+			})
+
+			return
+		}
+
+		authorizeOwner(c, policy.ResourceAlbum, uint(albumId), Album.UserId)
+	}
+}
+
+// authorizeOwner lets the owner of a row through and delegates everybody else
+// to the policy layer. Role based overrides are recorded before the handler
+// runs, so an override that cannot be recorded does not happen at all.
+func authorizeOwner(c *gin.Context, resource string, resourceID, ownerID uint) {
+	actor := policy.ActorFromClaims(c.MustGet("userData").(jwt.MapClaims))
+	action := policy.ActionForMethod(c.Request.Method)
+	decision := policy.Authorize(actor, action, resource, ownerID)
+
+	if !decision.Allowed {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "You are not authorized to access this resource",
+		})
+
+		return
+	}
+
+	if decision.Override {
+		if err := policy.RecordOverride(database.GetDB(), actor, action, resource, resourceID, ownerID); err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error":   "Internal Server Error",
+				"message": "Failed to record the override",
 			})
 
 			return
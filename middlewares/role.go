@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"final-project/policy"
+	"net/http"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+// RoleAuthorization only lets through users holding one of the given roles.
+func RoleAuthorization(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := policy.ActorFromClaims(c.MustGet("userData").(jwt.MapClaims))
+
+		if !policy.HasRole(actor, roles...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   "Forbidden",
+				"message": "You are not authorized to access this resource",
+			})
+
+			return
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"final-project/helpers"
+	"net/http"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+// Scope limits personal access tokens to the resources they were granted.
+// Safe methods need "<resource>:read", everything else "<resource>:write".
+// Requests authenticated with a JWT are not affected.
+func Scope(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userData := c.MustGet("userData").(jwt.MapClaims)
+		scopes, isPAT := userData["scopes"].([]string)
+
+		if !isPAT {
+			return
+		}
+
+		write := c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead
+
+		if !helpers.HasScope(scopes, resource, write) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   "Forbidden",
+				"message": "This token does not have the required scope",
+			})
+
+			return
+		}
+	}
+}
+
+// NoPersonalAccessToken keeps account and credential management endpoints
+// reserved for interactive logins.
+func NoPersonalAccessToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userData := c.MustGet("userData").(jwt.MapClaims)
+
+		if _, isPAT := userData["pat_id"]; isPAT {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   "Forbidden",
+				"message": "This endpoint cannot be used with a personal access token",
+			})
+
+			return
+		}
+	}
+}
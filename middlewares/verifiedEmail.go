@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"final-project/database"
+	"final-project/helpers"
+	"final-project/models"
+	"net/http"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+// VerifiedEmail rejects users who have not verified their email address when
+// REQUIRE_EMAIL_VERIFICATION is enabled. Unverified users can still log in.
+func VerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !helpers.GetEnvBool("REQUIRE_EMAIL_VERIFICATION", false) {
+			return
+		}
+
+		db := database.GetDB()
+		userData := c.MustGet("userData").(jwt.MapClaims)
+		userID := uint(userData["id"].(float64))
+		user := models.User{}
+
+		if err := db.Select("id", "verified_at").First(&user, userID).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error":   "Unauthorized",
+				"message": "User not found",
+			})
+
+			return
+		}
+
+		if user.VerifiedAt == nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   "Forbidden",
+				"message": "Please verify your email address first",
+			})
+
+			return
+		}
+	}
+}
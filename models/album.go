@@ -0,0 +1,54 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"gorm.io/gorm"
+)
+
+// Album is a user's collection of their own photos. CoverPhoto is chosen by
+// the owner; without one, the first photo of the album is shown.
+type Album struct {
+	GormModel
+	Title        string `json:"title" gorm:"not null" form:"title" valid:"required~Title is required"`
+	Description  string `json:"description" form:"description"`
+	CoverPhotoId *uint  `json:"cover_photo_id" form:"cover_photo_id"`
+	CoverPhoto   *Photo `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	UserId       uint   `json:"user_id" gorm:"not null" form:"-"`
+	User         *User  `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// AlbumPhoto places a photo in an album. Photos are shown by ascending
+// Position; a photo can be in an album only once.
+type AlbumPhoto struct {
+	AlbumId   uint       `json:"album_id" gorm:"primaryKey"`
+	Album     *Album     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	PhotoId   uint       `json:"photo_id" gorm:"primaryKey;index"`
+	Photo     *Photo     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Position  int        `json:"position" gorm:"not null"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+func (a *Album) BeforeCreate(tx *gorm.DB) (err error) {
+	_, errCreate := govalidator.ValidateStruct(a)
+
+	if errCreate != nil {
+		err = errCreate
+		return
+	}
+
+	err = nil
+	return
+}
+
+func (a *Album) BeforeUpdate(tx *gorm.DB) (err error) {
+	if a.Title == "" {
+		err = errors.New("Title is required")
+		return
+	}
+
+	err = nil
+	return
+}
@@ -0,0 +1,14 @@
+package models
+
+// AuditLog records every time a moderator or admin acted on content owned by
+// somebody else, and every role change.
+type AuditLog struct {
+	GormModel
+	ActorId      uint   `json:"actor_id" gorm:"not null;index"`
+	ActorRole    string `json:"actor_role" gorm:"not null"`
+	Action       string `json:"action" gorm:"not null"`
+	ResourceType string `json:"resource_type" gorm:"not null;index:idx_audit_logs_resource"`
+	ResourceId   uint   `json:"resource_id" gorm:"not null;index:idx_audit_logs_resource"`
+	OwnerId      uint   `json:"owner_id" gorm:"index"`
+	Detail       string `json:"detail"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// AuthorizationRequest keeps the state and PKCE verifier of a sign in at an
+// external identity provider until the provider redirects back. UserId is set
+// when a signed in user is linking another identity to their account.
+type AuthorizationRequest struct {
+	GormModel
+	Provider     string     `json:"provider" gorm:"not null"`
+	StateHash    string     `json:"-" gorm:"not null;uniqueIndex"`
+	CodeVerifier string     `json:"-" gorm:"not null"`
+	UserId       *uint      `json:"user_id" gorm:"index"`
+	User         *User      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt       *time.Time `json:"used_at"`
+}
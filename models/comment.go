@@ -12,7 +12,7 @@ type Comment struct {
 	Message string `json:"message" gorm:"not null" form:"message" valid:"required~Message is required"`
 	UserId  uint   `json:"user_id" form:"user_id"`
 	User    *User  `json:"user"`
-	PhotoId uint   `json:"photo_id" form:"photo_id"`
+	PhotoId uint   `json:"photo_id" form:"photo_id" gorm:"index"`
 	Photo   *Photo `json:"photo"`
 }
 
@@ -28,6 +28,24 @@ func (c *Comment) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+func (c *Comment) AfterCreate(tx *gorm.DB) (err error) {
+	return syncCommentTags(tx, c.ID, c.Message)
+}
+
+// AfterUpdate re-reads the message for the same reason as Photo.AfterUpdate.
+func (c *Comment) AfterUpdate(tx *gorm.DB) (err error) {
+	if c.ID == 0 {
+		return nil
+	}
+
+	messages := []string{}
+	if err := tx.Model(&Comment{}).Where("id = ?", c.ID).Pluck("message", &messages).Error; err != nil || len(messages) == 0 {
+		return err
+	}
+
+	return syncCommentTags(tx, c.ID, messages[0])
+}
+
 func (c *Comment) BeforeUpdate(tx *gorm.DB) (err error) {
 	if c.Message == "" {
 		err = errors.New("Message is required")
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+type EmailVerificationToken struct {
+	GormModel
+	UserId    uint       `json:"user_id" gorm:"not null;index"`
+	User      *User      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+}
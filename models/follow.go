@@ -0,0 +1,11 @@
+package models
+
+// Follow records that Follower follows Followee. The unique index rejects
+// duplicate follows and the check constraint rejects following oneself.
+type Follow struct {
+	GormModel
+	FollowerId uint  `json:"follower_id" gorm:"not null;uniqueIndex:idx_follows_follower_followee;check:chk_follows_not_self,follower_id <> followee_id"`
+	Follower   *User `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	FolloweeId uint  `json:"followee_id" gorm:"not null;uniqueIndex:idx_follows_follower_followee;index"`
+	Followee   *User `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
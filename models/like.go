@@ -0,0 +1,10 @@
+package models
+
+// Like is a user's like of a photo; a user can like each photo once.
+type Like struct {
+	GormModel
+	UserId  uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_likes_user_photo"`
+	User    *User  `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	PhotoId uint   `json:"photo_id" gorm:"not null;uniqueIndex:idx_likes_user_photo;index"`
+	Photo   *Photo `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
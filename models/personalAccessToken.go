@@ -0,0 +1,25 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// PersonalAccessToken lets scripts authenticate without a password. Scopes is
+// a space separated list such as "photos:read comments:write".
+type PersonalAccessToken struct {
+	GormModel
+	UserId     uint       `json:"user_id" gorm:"not null;index"`
+	User       *User      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Name       string     `json:"name" gorm:"not null"`
+	Prefix     string     `json:"prefix" gorm:"not null"`
+	TokenHash  string     `json:"-" gorm:"not null;uniqueIndex"`
+	Scopes     string     `json:"-" gorm:"not null"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+func (p *PersonalAccessToken) ScopeList() []string {
+	return strings.Fields(p.Scopes)
+}
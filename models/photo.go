@@ -2,6 +2,7 @@ package models
 
 import (
 	"errors"
+	"time"
 
 	"github.com/asaskevich/govalidator"
 	"gorm.io/gorm"
@@ -12,8 +13,22 @@ type Photo struct {
 	Title    string `json:"title" gorm:"not null" form:"title" valid:"required~Title is required"`
 	Caption  string `json:"caption" form:"caption"`
 	PhotoUrl string `json:"photo_url" gorm:"not null" form:"photo_url" valid:"required~PhotoUrl is required, url~Invalid URL format"`
+	MediaKey string `json:"media_key,omitempty" gorm:"index" form:"-"`
+	Status   string `json:"status" gorm:"not null;default:ready" form:"-"`
 	UserId   uint   `json:"user_id" form:"user_id"`
 	User     *User  `json:"User"`
+
+	// Read from the EXIF metadata of uploaded files.
+	TakenAt     *time.Time `json:"taken_at" form:"-"`
+	CameraModel string     `json:"camera_model" form:"-"`
+	Orientation int        `json:"orientation" gorm:"not null;default:1" form:"-"`
+	Latitude    *float64   `json:"latitude" form:"-"`
+	Longitude   *float64   `json:"longitude" form:"-"`
+
+	// PerceptualHash is the dHash of uploaded files, see package phash.
+	PerceptualHash *int64 `json:"-" form:"-"`
+
+	Renditions []PhotoRendition `json:"-" form:"-"`
 }
 
 func (p *Photo) BeforeCreate(tx *gorm.DB) (err error) {
@@ -28,6 +43,25 @@ func (p *Photo) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+func (p *Photo) AfterCreate(tx *gorm.DB) (err error) {
+	return syncPhotoTags(tx, p.ID, p.Caption)
+}
+
+// AfterUpdate re-reads the caption because Updates may not have changed it
+// and p then only holds the updated fields.
+func (p *Photo) AfterUpdate(tx *gorm.DB) (err error) {
+	if p.ID == 0 {
+		return nil
+	}
+
+	captions := []string{}
+	if err := tx.Model(&Photo{}).Where("id = ?", p.ID).Pluck("caption", &captions).Error; err != nil || len(captions) == 0 {
+		return err
+	}
+
+	return syncPhotoTags(tx, p.ID, captions[0])
+}
+
 func (p *Photo) BeforeUpdate(tx *gorm.DB) (err error) {
 	if p.Title == "" && p.PhotoUrl == "" {
 		err = errors.New("Title and PhotoUrl is required")
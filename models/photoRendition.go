@@ -0,0 +1,21 @@
+package models
+
+// Photo statuses. Uploaded photos are processing until their renditions have
+// been generated; photos linked by URL are ready right away.
+const (
+	PhotoStatusProcessing = "processing"
+	PhotoStatusReady      = "ready"
+	PhotoStatusFailed     = "failed"
+)
+
+// PhotoRendition is a smaller copy of an uploaded photo whose longest edge is
+// at most Size pixels.
+type PhotoRendition struct {
+	GormModel
+	PhotoId  uint   `json:"photo_id" gorm:"not null;uniqueIndex:idx_photo_renditions_photo_size"`
+	Photo    *Photo `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Size     int    `json:"size" gorm:"not null;uniqueIndex:idx_photo_renditions_photo_size"`
+	Width    int    `json:"width" gorm:"not null"`
+	Height   int    `json:"height" gorm:"not null"`
+	MediaKey string `json:"media_key" gorm:"not null;index"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type RecoveryCode struct {
+	GormModel
+	UserId   uint       `json:"user_id" gorm:"not null;index"`
+	User     *User      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	CodeHash string     `json:"-" gorm:"not null;index"`
+	UsedAt   *time.Time `json:"used_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// RevokedToken blocks a single access token by Jti, or every access token of
+// UserId issued before RevokedBefore when Jti is empty. Rows only need to live
+// until the tokens they block would have expired anyway.
+type RevokedToken struct {
+	GormModel
+	Jti           string     `json:"jti" gorm:"index"`
+	UserId        uint       `json:"user_id" gorm:"not null;index"`
+	RevokedBefore *time.Time `json:"revoked_before"`
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"not null;index"`
+}
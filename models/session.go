@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Session is one login on one device. It owns the refresh token family issued
+// at login, and access tokens carry its FamilyId in the "sid" claim.
+type Session struct {
+	GormModel
+	UserId       uint       `json:"user_id" gorm:"not null;index"`
+	User         *User      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	FamilyId     string     `json:"-" gorm:"not null;uniqueIndex"`
+	UserAgent    string     `json:"user_agent"`
+	Ip           string     `json:"ip"`
+	LastSeenAt   *time.Time `json:"last_seen_at"`
+	TerminatedAt *time.Time `json:"terminated_at" gorm:"index"`
+}
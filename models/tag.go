@@ -0,0 +1,87 @@
+package models
+
+import (
+	"final-project/helpers"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Tag is a normalized hashtag, stored lower case without the leading #.
+type Tag struct {
+	GormModel
+	Name string `json:"name" gorm:"not null;uniqueIndex"`
+}
+
+// PhotoTag links a photo to a hashtag of its caption. CreatedAt is when the
+// tag was first used on the photo, which is what trending counts.
+type PhotoTag struct {
+	PhotoId   uint      `json:"photo_id" gorm:"primaryKey"`
+	Photo     *Photo    `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	TagId     uint      `json:"tag_id" gorm:"primaryKey;index"`
+	Tag       *Tag      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null;index"`
+}
+
+// CommentTag links a comment to a hashtag of its message.
+type CommentTag struct {
+	CommentId uint      `json:"comment_id" gorm:"primaryKey"`
+	Comment   *Comment  `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	TagId     uint      `json:"tag_id" gorm:"primaryKey;index"`
+	Tag       *Tag      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null;index"`
+}
+
+// syncTags makes the links of one photo or comment match the hashtags in
+// text. Links that stay keep their CreatedAt; links of removed hashtags are
+// deleted. Deleting the photo or comment itself removes its links through
+// the foreign key cascade.
+func syncTags(tx *gorm.DB, link interface{}, ownerColumn string, ownerID uint, text string, newLink func(tagID uint) interface{}) error {
+	names := helpers.ParseHashtags(text)
+	tagIDs := []uint{}
+
+	if len(names) > 0 {
+		tags := make([]Tag, len(names))
+		for i, name := range names {
+			tags[i] = Tag{Name: name}
+		}
+
+		if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).Create(&tags).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&Tag{}).Where("name IN ?", names).Pluck("id", &tagIDs).Error; err != nil {
+			return err
+		}
+	}
+
+	stale := tx.Where(ownerColumn+" = ?", ownerID)
+	if len(tagIDs) > 0 {
+		stale = stale.Where("tag_id NOT IN ?", tagIDs)
+	}
+
+	if err := stale.Delete(link).Error; err != nil {
+		return err
+	}
+
+	for _, tagID := range tagIDs {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(newLink(tagID)).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func syncPhotoTags(tx *gorm.DB, photoID uint, caption string) error {
+	return syncTags(tx, &PhotoTag{}, "photo_id", photoID, caption, func(tagID uint) interface{} {
+		return &PhotoTag{PhotoId: photoID, TagId: tagID}
+	})
+}
+
+func syncCommentTags(tx *gorm.DB, commentID uint, message string) error {
+	return syncTags(tx, &CommentTag{}, "comment_id", commentID, message, func(tagID uint) interface{} {
+		return &CommentTag{CommentId: commentID, TagId: tagID}
+	})
+}
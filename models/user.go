@@ -3,6 +3,7 @@ package models
 import (
 	"errors"
 	"final-project/helpers"
+	"time"
 
 	"github.com/asaskevich/govalidator"
 	"gorm.io/gorm"
@@ -10,11 +11,19 @@ import (
 
 type User struct {
 	GormModel
-	Username        string `json:"username" gorm:"unique;not null" form:"username" valid:"required~Username is required"`
-	Email           string `json:"email" gorm:"unique;not null" form:"email" valid:"required~Email is required, email~Email is invalid"`
-	Password        string `json:"password" gorm:"not null" form:"password" valid:"required~Password is required, minstringlength(6)~Password must be at least 6 characters"`
-	ProfileImageURL string `json:"profile_image_url" form:"profile_image_url" valid:"required~Profile Image URL is required, url~Invalid URL format"`
-	Age             int    `json:"age" gorm:"not null" form:"age" valid:"required~Age is required, range(8|100)~Age must be at least 8"`
+	Username        string     `json:"username" gorm:"unique;not null" form:"username" valid:"required~Username is required"`
+	Email           string     `json:"email" gorm:"unique;not null" form:"email" valid:"required~Email is required, email~Email is invalid"`
+	Password        string     `json:"password" gorm:"not null" form:"password" valid:"required~Password is required, minstringlength(6)~Password must be at least 6 characters"`
+	ProfileImageURL string     `json:"profile_image_url" form:"profile_image_url" valid:"required~Profile Image URL is required, url~Invalid URL format"`
+	Age             int        `json:"age" gorm:"not null" form:"age" valid:"required~Age is required, range(8|100)~Age must be at least 8"`
+	ShowAge         bool       `json:"show_age" gorm:"not null;default:false" form:"show_age"`
+	ShowTakenAt     bool       `json:"show_taken_at" gorm:"not null;default:false" form:"show_taken_at"`
+	ShowLocation    bool       `json:"show_location" gorm:"not null;default:false" form:"show_location"`
+	Role            string     `json:"role" gorm:"not null;default:user" form:"-"`
+	VerifiedAt      *time.Time `json:"verified_at" form:"-"`
+	TotpSecret      string     `json:"-" form:"-"`
+	TotpEnabledAt   *time.Time `json:"-" form:"-"`
+	TotpLastStep    int64      `json:"-" form:"-"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
@@ -25,9 +34,8 @@ func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 		return
 	}
 
-	u.Password = helpers.HashPassword(u.Password)
+	u.Password, err = helpers.HashPassword(u.Password)
 
-	err = nil
 	return
 }
 
@@ -0,0 +1,12 @@
+package models
+
+// UserIdentity links an account at an external identity provider, identified
+// by the provider's stable subject, to a user.
+type UserIdentity struct {
+	GormModel
+	UserId   uint   `json:"user_id" gorm:"not null;index"`
+	User     *User  `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Provider string `json:"provider" gorm:"not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Subject  string `json:"-" gorm:"not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Email    string `json:"email"`
+}
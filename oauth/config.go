@@ -0,0 +1,156 @@
+package oauth
+
+import (
+	"encoding/json"
+	"final-project/helpers"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	providers map[string]*Provider
+	once      sync.Once
+	mu        sync.RWMutex
+)
+
+type discoveryDocument struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// OAUTH_PROVIDERS is a comma separated list of provider names. Each provider
+// is configured with OAUTH_<NAME>_CLIENT_ID, _CLIENT_SECRET and _SCOPES, plus
+// either _ISSUER for OIDC discovery or explicit _AUTH_URL, _TOKEN_URL and
+// _USERINFO_URL. _REDIRECT_URL defaults to APP_URL/users/oauth/<name>/callback.
+func loadProviders() {
+	loaded := map[string]*Provider{}
+
+	for _, name := range strings.Split(helpers.GetEnv("OAUTH_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		provider, err := providerFromEnv(name)
+		if err != nil {
+			log.Printf("Skipping OAuth provider %s: %s", name, err)
+			continue
+		}
+
+		loaded[name] = provider
+	}
+
+	providers = loaded
+}
+
+func providerFromEnv(name string) (*Provider, error) {
+	prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+	provider := &Provider{
+		Name:         name,
+		ClientID:     helpers.GetEnv(prefix+"CLIENT_ID", ""),
+		ClientSecret: helpers.GetEnv(prefix+"CLIENT_SECRET", ""),
+		AuthURL:      helpers.GetEnv(prefix+"AUTH_URL", ""),
+		TokenURL:     helpers.GetEnv(prefix+"TOKEN_URL", ""),
+		UserInfoURL:  helpers.GetEnv(prefix+"USERINFO_URL", ""),
+		RedirectURL:  helpers.GetEnv(prefix+"REDIRECT_URL", strings.TrimRight(helpers.GetEnv("APP_URL", "http://localhost:8080"), "/")+"/users/oauth/"+name+"/callback"),
+		Scopes:       strings.Fields(helpers.GetEnv(prefix+"SCOPES", "openid email profile")),
+	}
+
+	if provider.ClientID == "" {
+		return nil, fmt.Errorf("%sCLIENT_ID is required", prefix)
+	}
+
+	if issuer := helpers.GetEnv(prefix+"ISSUER", ""); issuer != "" {
+		document, err := discover(issuer)
+		if err != nil {
+			return nil, err
+		}
+
+		if provider.AuthURL == "" {
+			provider.AuthURL = document.AuthorizationEndpoint
+		}
+
+		if provider.TokenURL == "" {
+			provider.TokenURL = document.TokenEndpoint
+		}
+
+		if provider.UserInfoURL == "" {
+			provider.UserInfoURL = document.UserInfoEndpoint
+		}
+	}
+
+	if provider.AuthURL == "" || provider.TokenURL == "" || provider.UserInfoURL == "" {
+		return nil, fmt.Errorf("%sISSUER or the authorization, token and userinfo URLs are required", prefix)
+	}
+
+	return provider, nil
+}
+
+func discover(issuer string) (discoveryDocument, error) {
+	document := discoveryDocument{}
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	res, err := client.Get(strings.TrimRight(issuer, "/") + "/.well-known/openid-configuration")
+	if err != nil {
+		return document, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return document, fmt.Errorf("discovery failed with status %d", res.StatusCode)
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&document)
+
+	return document, err
+}
+
+// GetProvider returns the configured provider with the given name.
+func GetProvider(name string) (*Provider, bool) {
+	once.Do(loadProviders)
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	provider, ok := providers[strings.ToLower(name)]
+
+	return provider, ok
+}
+
+// ProviderNames lists the configured providers in alphabetical order.
+func ProviderNames() []string {
+	once.Do(loadProviders)
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// SetProviders replaces the configured providers, e.g. with one pointing at a
+// mock provider in tests.
+func SetProviders(list ...*Provider) {
+	once.Do(func() {})
+
+	loaded := map[string]*Provider{}
+	for _, provider := range list {
+		loaded[strings.ToLower(provider.Name)] = provider
+	}
+
+	mu.Lock()
+	providers = loaded
+	mu.Unlock()
+}
@@ -0,0 +1,38 @@
+package oauth_test
+
+import (
+	"final-project/oauth"
+	"final-project/oauth/oauthtest"
+	"testing"
+)
+
+func TestProviderFromEnvDiscovery(t *testing.T) {
+	server := oauthtest.NewServer()
+	defer server.Close()
+
+	t.Setenv("APP_URL", "https://app.example/")
+	t.Setenv("OAUTH_MOCK_CLIENT_ID", oauthtest.ClientID)
+	t.Setenv("OAUTH_MOCK_CLIENT_SECRET", oauthtest.ClientSecret)
+	t.Setenv("OAUTH_MOCK_ISSUER", server.URL)
+
+	provider, err := oauth.ProviderFromEnv("mock")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if provider.AuthURL != server.URL+"/authorize" || provider.TokenURL != server.URL+"/token" || provider.UserInfoURL != server.URL+"/userinfo" {
+		t.Fatalf("discovered endpoints %q, %q, %q", provider.AuthURL, provider.TokenURL, provider.UserInfoURL)
+	}
+
+	if provider.RedirectURL != "https://app.example/users/oauth/mock/callback" {
+		t.Fatalf("redirect URL = %q", provider.RedirectURL)
+	}
+}
+
+func TestProviderFromEnvRequiresEndpoints(t *testing.T) {
+	t.Setenv("OAUTH_MOCK_CLIENT_ID", "client")
+
+	if _, err := oauth.ProviderFromEnv("mock"); err == nil {
+		t.Fatal("a provider without issuer or endpoints was accepted")
+	}
+}
@@ -0,0 +1,3 @@
+package oauth
+
+var ProviderFromEnv = providerFromEnv
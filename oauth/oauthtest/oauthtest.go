@@ -0,0 +1,192 @@
+// Package oauthtest runs a local mock OpenID Connect provider for tests of
+// the sign in flow.
+package oauthtest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"final-project/oauth"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+const (
+	ClientID     = "test-client"
+	ClientSecret = "test-secret"
+	RedirectURL  = "http://localhost/callback"
+)
+
+// Identity is what the mock provider reports from its userinfo endpoint.
+type Identity struct {
+	Subject       string      `json:"sub"`
+	Email         string      `json:"email,omitempty"`
+	EmailVerified interface{} `json:"email_verified,omitempty"`
+	Name          string      `json:"name,omitempty"`
+}
+
+type grant struct {
+	challenge   string
+	redirectURI string
+	identity    Identity
+}
+
+// Server is the mock provider. It serves discovery, token and userinfo
+// endpoints; the consent page is replaced by Authorize.
+type Server struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	next   int
+	codes  map[string]grant
+	tokens map[string]Identity
+}
+
+// NewServer starts a mock provider; close it with Close.
+func NewServer() *Server {
+	s := &Server{codes: map[string]grant{}, tokens: map[string]Identity{}}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/userinfo", s.userInfo)
+
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Provider returns a provider configured for the mock server under name.
+func (s *Server) Provider(name string) *oauth.Provider {
+	return &oauth.Provider{
+		Name:         name,
+		ClientID:     ClientID,
+		ClientSecret: ClientSecret,
+		AuthURL:      s.URL + "/authorize",
+		TokenURL:     s.URL + "/token",
+		UserInfoURL:  s.URL + "/userinfo",
+		RedirectURL:  RedirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+		Client:       s.Client(),
+	}
+}
+
+// Authorize plays the user signing in as identity at the authorization URL.
+// It checks the request like a provider would and returns the code and state
+// the provider would redirect back with.
+func (s *Server) Authorize(authorizationURL string, identity Identity) (code, state string, err error) {
+	u, err := url.Parse(authorizationURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	query := u.Query()
+
+	switch {
+	case u.Path != "/authorize":
+		return "", "", fmt.Errorf("unexpected authorization path %q", u.Path)
+	case query.Get("response_type") != "code":
+		return "", "", errors.New("response_type must be code")
+	case query.Get("client_id") != ClientID:
+		return "", "", errors.New("unknown client_id")
+	case query.Get("state") == "":
+		return "", "", errors.New("state is missing")
+	case query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256":
+		return "", "", errors.New("an S256 code challenge is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.next++
+	code = "code-" + strconv.Itoa(s.next)
+	s.codes[code] = grant{
+		challenge:   query.Get("code_challenge"),
+		redirectURI: query.Get("redirect_uri"),
+		identity:    identity,
+	}
+
+	return code, query.Get("state"), nil
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 s.URL,
+		"authorization_endpoint": s.URL + "/authorize",
+		"token_endpoint":         s.URL + "/token",
+		"userinfo_endpoint":      s.URL + "/userinfo",
+	})
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	clientID, secret, ok := r.BasicAuth()
+	if !ok || clientID != ClientID || secret != ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Codes are single use, whether the exchange succeeds or not.
+	code := r.PostFormValue("code")
+	granted, ok := s.codes[code]
+	delete(s.codes, code)
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+
+	switch {
+	case r.PostFormValue("grant_type") != "authorization_code":
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+	case !ok || r.PostFormValue("redirect_uri") != granted.redirectURI:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+	case base64.RawURLEncoding.EncodeToString(sum[:]) != granted.challenge:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "code_verifier does not match"})
+	default:
+		s.next++
+		token := "token-" + strconv.Itoa(s.next)
+		s.tokens[token] = granted.identity
+
+		writeJSON(w, http.StatusOK, map[string]string{"access_token": token, "token_type": "Bearer"})
+	}
+}
+
+func (s *Server) userInfo(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	identity, ok := s.tokens[bearer(r)]
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, identity)
+}
+
+func bearer(r *http.Request) string {
+	const prefix = "Bearer "
+
+	header := r.Header.Get("Authorization")
+	if len(header) <= len(prefix) || header[:len(prefix)] != prefix {
+		return ""
+	}
+
+	return header[len(prefix):]
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,19 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"final-project/helpers"
+)
+
+// NewCodeVerifier returns a PKCE code verifier of 43 URL-safe characters.
+func NewCodeVerifier() (string, error) {
+	return helpers.GenerateRandomToken(32)
+}
+
+// CodeChallenge derives the S256 code challenge sent with the authorization request.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
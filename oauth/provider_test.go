@@ -0,0 +1,131 @@
+package oauth_test
+
+import (
+	"final-project/oauth"
+	"final-project/oauth/oauthtest"
+	"net/url"
+	"testing"
+)
+
+func authorize(t *testing.T, server *oauthtest.Server, provider *oauth.Provider, identity oauthtest.Identity) (code, verifier string) {
+	t.Helper()
+
+	verifier, err := oauth.NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL := provider.AuthCodeURL("some-state", oauth.CodeChallenge(verifier))
+
+	code, state, err := server.Authorize(authURL, identity)
+	if err != nil {
+		t.Fatalf("authorize: %s", err)
+	}
+
+	if state != "some-state" {
+		t.Fatalf("state = %q, want some-state", state)
+	}
+
+	return code, verifier
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	provider := &oauth.Provider{
+		ClientID:    "client",
+		AuthURL:     "https://idp.example/authorize?prompt=login",
+		RedirectURL: "https://app.example/callback",
+		Scopes:      []string{"openid", "email"},
+	}
+
+	u, err := url.Parse(provider.AuthCodeURL("state", "challenge"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"prompt":                "login",
+		"response_type":         "code",
+		"client_id":             "client",
+		"redirect_uri":          "https://app.example/callback",
+		"scope":                 "openid email",
+		"state":                 "state",
+		"code_challenge":        "challenge",
+		"code_challenge_method": "S256",
+	}
+
+	for key, value := range want {
+		if got := u.Query().Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestExchangeAndUserInfo(t *testing.T) {
+	server := oauthtest.NewServer()
+	defer server.Close()
+
+	provider := server.Provider("mock")
+	code, verifier := authorize(t, server, provider, oauthtest.Identity{
+		Subject:       "subject-1",
+		Email:         "someone@example.com",
+		EmailVerified: "true",
+		Name:          "Someone",
+	})
+
+	accessToken, err := provider.Exchange(code, verifier)
+	if err != nil {
+		t.Fatalf("exchange: %s", err)
+	}
+
+	identity, err := provider.UserInfo(accessToken)
+	if err != nil {
+		t.Fatalf("userinfo: %s", err)
+	}
+
+	want := oauth.Identity{Subject: "subject-1", Email: "someone@example.com", EmailVerified: true, Name: "Someone"}
+	if identity != want {
+		t.Fatalf("identity = %+v, want %+v", identity, want)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	server := oauthtest.NewServer()
+	defer server.Close()
+
+	provider := server.Provider("mock")
+	code, _ := authorize(t, server, provider, oauthtest.Identity{Subject: "subject-1"})
+
+	other, err := oauth.NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := provider.Exchange(code, other); err == nil {
+		t.Fatal("exchange with the wrong code verifier succeeded")
+	}
+}
+
+func TestExchangeRejectsReusedCode(t *testing.T) {
+	server := oauthtest.NewServer()
+	defer server.Close()
+
+	provider := server.Provider("mock")
+	code, verifier := authorize(t, server, provider, oauthtest.Identity{Subject: "subject-1"})
+
+	if _, err := provider.Exchange(code, verifier); err != nil {
+		t.Fatalf("first exchange: %s", err)
+	}
+
+	if _, err := provider.Exchange(code, verifier); err == nil {
+		t.Fatal("second exchange of the same code succeeded")
+	}
+}
+
+func TestUserInfoRejectsUnknownToken(t *testing.T) {
+	server := oauthtest.NewServer()
+	defer server.Close()
+
+	if _, err := server.Provider("mock").UserInfo("not-a-token"); err == nil {
+		t.Fatal("userinfo with an unknown token succeeded")
+	}
+}
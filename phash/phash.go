@@ -0,0 +1,84 @@
+package phash
+
+import (
+	"bytes"
+	"final-project/exif"
+	"final-project/imaging"
+	"image"
+	"image/draw"
+	"math/bits"
+)
+
+// Compute returns the difference hash of an encoded image, turned upright
+// first when its EXIF metadata says so. Formats the standard library cannot
+// decode return image.ErrFormat, images above imaging.MaxPixels
+// imaging.ErrTooManyPixels.
+func Compute(data []byte) (uint64, error) {
+	if _, _, err := imaging.DecodeConfig(data); err != nil {
+		return 0, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+
+	if meta, err := exif.Parse(data); err == nil {
+		img = exif.Orient(img, meta.Orientation)
+	}
+
+	return DHash(img), nil
+}
+
+// DHash is the 64 bit difference hash of img: the image is shrunk to 9x8
+// gray pixels and every bit tells whether a pixel is brighter than its right
+// neighbour. Resized, recompressed or slightly edited copies of an image have
+// hashes only a few bits apart.
+func DHash(img image.Image) uint64 {
+	bounds := img.Bounds()
+	gray, ok := img.(*image.Gray)
+	if !ok || bounds.Min != (image.Point{}) {
+		gray = image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(gray, gray.Bounds(), img, bounds.Min, draw.Src)
+	}
+
+	const width, height = 9, 8
+
+	var cells [height][width]uint64
+
+	for y := 0; y < height; y++ {
+		y0, y1 := imaging.Span(y, height, bounds.Dy())
+
+		for x := 0; x < width; x++ {
+			x0, x1 := imaging.Span(x, width, bounds.Dx())
+
+			var sum, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					sum += uint64(gray.Pix[sy*gray.Stride+sx])
+					n++
+				}
+			}
+
+			cells[y][x] = sum / max(n, 1)
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width-1; x++ {
+			hash <<= 1
+			if cells[y][x] > cells[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash
+}
+
+// Distance is the number of bits two hashes differ in, 0 for identical
+// images and up to 64.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
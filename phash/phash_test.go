@@ -0,0 +1,113 @@
+package phash_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"final-project/imaging"
+	"final-project/phash"
+	"final-project/renditions"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// scene is a w x h picture of 9 x 8 blocks of random colors, with a
+// gradient across each block; different seeds give unrelated pictures.
+func scene(w, h int, seed int64) *image.NRGBA {
+	random := rand.New(rand.NewSource(seed))
+	blocks := [8][9]color.NRGBA{}
+	for y := range blocks {
+		for x := range blocks[y] {
+			blocks[y][x] = color.NRGBA{R: uint8(random.Intn(256)), G: uint8(random.Intn(256)), B: uint8(random.Intn(256)), A: 255}
+		}
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			block := blocks[y*8/h][x*9/w]
+			shade := uint8(x * 9 % w * 32 / w)
+			img.Set(x, y, color.NRGBA{R: block.R/2 + shade, G: block.G/2 + shade, B: block.B/2 + shade, A: 255})
+		}
+	}
+
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image, quality int) []byte {
+	t.Helper()
+
+	out := bytes.Buffer{}
+	if err := jpeg.Encode(&out, img, &jpeg.Options{Quality: quality}); err != nil {
+		t.Fatal(err)
+	}
+
+	return out.Bytes()
+}
+
+func compute(t *testing.T, data []byte) uint64 {
+	t.Helper()
+
+	hash, err := phash.Compute(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return hash
+}
+
+func TestComputeFindsCopies(t *testing.T) {
+	original := scene(360, 320, 1)
+	hash := compute(t, encodeJPEG(t, original, 95))
+
+	copies := map[string][]byte{
+		"recompressed": encodeJPEG(t, original, 40),
+		"resized":      encodeJPEG(t, renditions.Resize(original, 180, 160), 80),
+	}
+
+	for name, data := range copies {
+		if d := phash.Distance(hash, compute(t, data)); d > 4 {
+			t.Errorf("%s copy is %d bits away", name, d)
+		}
+	}
+
+	if d := phash.Distance(hash, compute(t, encodeJPEG(t, scene(360, 320, 2), 95))); d < 16 {
+		t.Errorf("unrelated image is only %d bits away", d)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	if d := phash.Distance(0, math.MaxUint64); d != 64 {
+		t.Errorf("Distance(0, max) = %d", d)
+	}
+
+	if d := phash.Distance(0b1011, 0b0110); d != 3 {
+		t.Errorf("Distance(1011, 0110) = %d", d)
+	}
+}
+
+func TestComputeRejectsTooManyPixels(t *testing.T) {
+	out := bytes.Buffer{}
+	if err := png.Encode(&out, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Claim 10000 x 10000 pixels in the IHDR chunk of a tiny file.
+	data := out.Bytes()
+	binary.BigEndian.PutUint32(data[16:], 10000)
+	binary.BigEndian.PutUint32(data[20:], 10000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	if _, err := phash.Compute(data); err != imaging.ErrTooManyPixels {
+		t.Fatalf("error = %v, want ErrTooManyPixels", err)
+	}
+
+	if _, err := phash.Compute([]byte("not an image")); err != image.ErrFormat {
+		t.Fatalf("error = %v, want image.ErrFormat", err)
+	}
+}
@@ -0,0 +1,28 @@
+package policy
+
+import (
+	"final-project/helpers"
+	"final-project/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// BootstrapAdmins grants the admin role to the accounts listed in the comma
+// separated ADMIN_EMAILS variable so a fresh deployment has someone who can
+// assign roles.
+func BootstrapAdmins(db *gorm.DB) error {
+	emails := []string{}
+
+	for _, email := range strings.Split(helpers.GetEnv("ADMIN_EMAILS", ""), ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			emails = append(emails, email)
+		}
+	}
+
+	if len(emails) == 0 {
+		return nil
+	}
+
+	return db.Model(&models.User{}).Where("email IN ? AND role <> ?", emails, models.RoleAdmin).UpdateColumn("role", models.RoleAdmin).Error
+}
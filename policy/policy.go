@@ -0,0 +1,109 @@
+package policy
+
+import (
+	"final-project/models"
+	"fmt"
+	"net/http"
+
+	"github.com/dgrijalva/jwt-go"
+	"gorm.io/gorm"
+)
+
+const (
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Resources whose rows moderators and admins may change on behalf of the owner.
+const (
+	ResourcePhoto       = "photo"
+	ResourceComment     = "comment"
+	ResourceSocialMedia = "social_media"
+	ResourceAlbum       = "album"
+)
+
+type Actor struct {
+	ID   uint
+	Role string
+}
+
+// Decision tells whether an action is allowed and whether it is only allowed
+// because of the actor's role, in which case it has to be recorded.
+type Decision struct {
+	Allowed  bool
+	Override bool
+}
+
+func ActorFromClaims(claims jwt.MapClaims) Actor {
+	id, _ := claims["id"].(float64)
+	role, _ := claims["role"].(string)
+
+	if role == "" {
+		role = models.RoleUser
+	}
+
+	return Actor{ID: uint(id), Role: role}
+}
+
+func ActionForMethod(method string) string {
+	if method == http.MethodDelete {
+		return ActionDelete
+	}
+
+	return ActionUpdate
+}
+
+// Authorize decides whether actor may perform action on a row of resource
+// owned by ownerID. Owners can always act on their own rows; moderators and
+// admins can update or delete any photo, comment, social media entry or album.
+func Authorize(actor Actor, action, resource string, ownerID uint) Decision {
+	if actor.ID == ownerID {
+		return Decision{Allowed: true}
+	}
+
+	if action != ActionUpdate && action != ActionDelete {
+		return Decision{}
+	}
+
+	switch resource {
+	case ResourcePhoto, ResourceComment, ResourceSocialMedia, ResourceAlbum:
+		if HasRole(actor, models.RoleModerator, models.RoleAdmin) {
+			return Decision{Allowed: true, Override: true}
+		}
+	}
+
+	return Decision{}
+}
+
+func HasRole(actor Actor, roles ...string) bool {
+	for _, role := range roles {
+		if actor.Role == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+func RecordOverride(db *gorm.DB, actor Actor, action, resource string, resourceID, ownerID uint) error {
+	return db.Create(&models.AuditLog{
+		ActorId:      actor.ID,
+		ActorRole:    actor.Role,
+		Action:       action,
+		ResourceType: resource,
+		ResourceId:   resourceID,
+		OwnerId:      ownerID,
+	}).Error
+}
+
+func RecordRoleChange(db *gorm.DB, actor Actor, userID uint, from, to string) error {
+	return db.Create(&models.AuditLog{
+		ActorId:      actor.ID,
+		ActorRole:    actor.Role,
+		Action:       "change_role",
+		ResourceType: "user",
+		ResourceId:   userID,
+		OwnerId:      userID,
+		Detail:       fmt.Sprintf("%s -> %s", from, to),
+	}).Error
+}
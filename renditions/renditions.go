@@ -0,0 +1,209 @@
+package renditions
+
+import (
+	"bytes"
+	"context"
+	"final-project/exif"
+	"final-project/helpers"
+	"final-project/imaging"
+	"final-project/models"
+	"final-project/phash"
+	"final-project/storage"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	queue     chan uint
+	startOnce sync.Once
+)
+
+// Sizes returns THUMBNAIL_SIZES, the longest edges in pixels renditions are
+// generated for, in ascending order.
+func Sizes() []int {
+	sizes := []int{}
+	seen := map[int]bool{}
+
+	for _, value := range strings.Split(helpers.GetEnv("THUMBNAIL_SIZES", "150,640,1080"), ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || size <= 0 || seen[size] {
+			continue
+		}
+
+		seen[size] = true
+		sizes = append(sizes, size)
+	}
+
+	sort.Ints(sizes)
+
+	return sizes
+}
+
+// Start runs THUMBNAIL_WORKERS background workers and queues the photos that
+// were still processing when the server last stopped.
+func Start(db *gorm.DB) {
+	startOnce.Do(func() {
+		queue = make(chan uint, 256)
+
+		for i := 0; i < max(1, helpers.GetEnvInt("THUMBNAIL_WORKERS", 2)); i++ {
+			go work(db)
+		}
+
+		pending := []uint{}
+		if err := db.Model(&models.Photo{}).Where("status = ?", models.PhotoStatusProcessing).Pluck("id", &pending).Error; err != nil {
+			log.Println("Failed to load photos waiting for renditions: ", err)
+		}
+
+		for _, id := range pending {
+			Enqueue(id)
+		}
+	})
+}
+
+// Enqueue schedules the renditions of a photo. It never blocks the request
+// that stored the photo.
+func Enqueue(photoID uint) {
+	if queue == nil {
+		log.Printf("Renditions of photo %d not generated: workers are not running", photoID)
+		return
+	}
+
+	select {
+	case queue <- photoID:
+	default:
+		go func() { queue <- photoID }()
+	}
+}
+
+func work(db *gorm.DB) {
+	for photoID := range queue {
+		if err := Generate(context.Background(), db, photoID); err != nil {
+			log.Printf("Failed to generate renditions of photo %d: %s", photoID, err)
+		}
+	}
+}
+
+// Generate stores a rendition of the photo for every size smaller than the
+// original and marks the photo ready, or failed when that is not possible.
+func Generate(ctx context.Context, db *gorm.DB, photoID uint) error {
+	photo := models.Photo{}
+	if err := db.Select("id", "media_key", "perceptual_hash").First(&photo, photoID).Error; err != nil {
+		return err
+	}
+
+	status := models.PhotoStatusReady
+	err := generate(ctx, db, photo)
+
+	if err == image.ErrFormat {
+		// Formats the standard library cannot decode, such as WebP, are
+		// served in their original size only.
+		err = nil
+	} else if err != nil {
+		status = models.PhotoStatusFailed
+	}
+
+	if updateErr := db.Model(&photo).UpdateColumn("status", status).Error; updateErr != nil && err == nil {
+		err = updateErr
+	}
+
+	return err
+}
+
+func generate(ctx context.Context, db *gorm.DB, photo models.Photo) error {
+	if photo.MediaKey == "" {
+		return nil
+	}
+
+	st := storage.GetStorage()
+
+	object, err := st.Get(ctx, photo.MediaKey)
+	if err != nil {
+		return err
+	}
+
+	data := bytes.Buffer{}
+	_, err = data.ReadFrom(object.Body)
+	object.Body.Close()
+
+	if err != nil {
+		return err
+	}
+
+	_, format, err := imaging.DecodeConfig(data.Bytes())
+	if err != nil {
+		return err
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data.Bytes()))
+	if err != nil {
+		return err
+	}
+
+	// Files stored with UPLOAD_STRIP_METADATA disabled may still need turning.
+	if meta, err := exif.Parse(data.Bytes()); err == nil {
+		src = exif.Orient(src, meta.Orientation)
+	}
+
+	// Uploads that predate perceptual hashes get theirs when processed.
+	if photo.PerceptualHash == nil {
+		if err := db.Model(&photo).UpdateColumn("perceptual_hash", int64(phash.DHash(src))).Error; err != nil {
+			return err
+		}
+	}
+
+	srcWidth, srcHeight := src.Bounds().Dx(), src.Bounds().Dy()
+	longest := max(srcWidth, srcHeight)
+
+	for _, size := range Sizes() {
+		if size >= longest {
+			break
+		}
+
+		width, height := Fit(srcWidth, srcHeight, size)
+		resized := Resize(src, width, height)
+
+		encoded := bytes.Buffer{}
+		if format == "jpeg" || resized.Opaque() {
+			err = jpeg.Encode(&encoded, resized, &jpeg.Options{Quality: helpers.GetEnvInt("THUMBNAIL_QUALITY", 85)})
+		} else {
+			err = png.Encode(&encoded, resized)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		key, _, err := storage.Save(ctx, st, bytes.NewReader(encoded.Bytes()))
+		if err != nil {
+			return fmt.Errorf("storing %dpx rendition: %w", size, err)
+		}
+
+		err = db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "photo_id"}, {Name: "size"}},
+			DoUpdates: clause.AssignmentColumns([]string{"width", "height", "media_key", "updated_at"}),
+		}).Create(&models.PhotoRendition{
+			PhotoId:  photo.ID,
+			Size:     size,
+			Width:    width,
+			Height:   height,
+			MediaKey: key,
+		}).Error
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package renditions
+
+import (
+	"final-project/imaging"
+	"image"
+	"image/draw"
+)
+
+// Fit returns the size of a width x height image scaled down so that its
+// longest edge is size pixels, keeping the aspect ratio.
+func Fit(width, height, size int) (int, int) {
+	if width >= height {
+		return size, max(1, (height*size+width/2)/width)
+	}
+
+	return max(1, (width*size+height/2)/height), size
+}
+
+// Resize scales src down to width x height. Every target pixel is the average
+// of the source pixels it covers, weighted by alpha so transparent pixels do
+// not darken the edges. It is meant for shrinking; enlarging repeats pixels.
+func Resize(src image.Image, width, height int) *image.NRGBA {
+	bounds := src.Bounds()
+	in, ok := src.(*image.NRGBA)
+	if !ok || bounds.Min != (image.Point{}) {
+		in = image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(in, in.Bounds(), src, bounds.Min, draw.Src)
+	}
+
+	out := image.NewNRGBA(image.Rect(0, 0, width, height))
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+
+	for y := 0; y < height; y++ {
+		y0, y1 := imaging.Span(y, height, srcHeight)
+
+		for x := 0; x < width; x++ {
+			x0, x1 := imaging.Span(x, width, srcWidth)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := in.Pix[sy*in.Stride : sy*in.Stride+srcWidth*4]
+
+				for sx := x0; sx < x1; sx++ {
+					pixel := row[sx*4 : sx*4+4]
+					alpha := uint64(pixel[3])
+
+					r += uint64(pixel[0]) * alpha
+					g += uint64(pixel[1]) * alpha
+					b += uint64(pixel[2]) * alpha
+					a += alpha
+					n++
+				}
+			}
+
+			pixel := out.Pix[y*out.Stride+x*4 : y*out.Stride+x*4+4]
+			if a > 0 {
+				pixel[0] = uint8((r + a/2) / a)
+				pixel[1] = uint8((g + a/2) / a)
+				pixel[2] = uint8((b + a/2) / a)
+			}
+
+			pixel[3] = uint8((a + n/2) / n)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,205 @@
+package revocation
+
+import (
+	"errors"
+	"final-project/database"
+	"final-project/helpers"
+	"final-project/models"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"gorm.io/gorm"
+)
+
+// The revoked_tokens table and sessions.terminated_at are the source of
+// truth; every instance keeps the rows that can still matter in memory so
+// Authentication never has to hit the database.
+var (
+	mu       sync.RWMutex
+	tokens   = map[string]time.Time{}
+	users    = map[uint]time.Time{}
+	sessions = map[string]time.Time{}
+	syncOnce sync.Once
+)
+
+// StartSync loads the revocation list and keeps it fresh so revocations made
+// by other instances are picked up within REVOCATION_SYNC_INTERVAL.
+func StartSync() {
+	syncOnce.Do(func() {
+		if err := Load(); err != nil {
+			log.Println("Failed to load revoked tokens: ", err)
+		}
+
+		go func() {
+			ticker := time.NewTicker(helpers.GetEnvDuration("REVOCATION_SYNC_INTERVAL", 30*time.Second))
+			defer ticker.Stop()
+
+			for range ticker.C {
+				if err := Load(); err != nil {
+					log.Println("Failed to sync revoked tokens: ", err)
+				}
+			}
+		}()
+	})
+}
+
+func Load() error {
+	db := database.GetDB()
+	now := time.Now()
+	rows := []models.RevokedToken{}
+
+	if err := db.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error; err != nil {
+		return err
+	}
+
+	if err := db.Where("expires_at >= ?", now).Find(&rows).Error; err != nil {
+		return err
+	}
+
+	// Access tokens of a terminated session stay valid for at most one TTL.
+	terminated := []models.Session{}
+	if err := db.Select("family_id", "terminated_at").Where("terminated_at >= ?", now.Add(-helpers.AccessTokenTTL())).Find(&terminated).Error; err != nil {
+		return err
+	}
+
+	loadedTokens := map[string]time.Time{}
+	loadedUsers := map[uint]time.Time{}
+	loadedSessions := map[string]time.Time{}
+
+	for _, row := range rows {
+		if row.Jti != "" {
+			loadedTokens[row.Jti] = row.ExpiresAt
+		}
+
+		if row.RevokedBefore != nil && row.RevokedBefore.After(loadedUsers[row.UserId]) {
+			loadedUsers[row.UserId] = *row.RevokedBefore
+		}
+	}
+
+	for _, session := range terminated {
+		loadedSessions[session.FamilyId] = session.TerminatedAt.Add(helpers.AccessTokenTTL())
+	}
+
+	mu.Lock()
+	tokens = loadedTokens
+	users = loadedUsers
+	sessions = loadedSessions
+	mu.Unlock()
+
+	return nil
+}
+
+// RevokeToken blocks the access token described by claims until it expires.
+func RevokeToken(claims jwt.MapClaims) error {
+	jti, _ := claims["jti"].(string)
+	userID, _ := claims["id"].(float64)
+	exp, _ := claims["exp"].(float64)
+	expiresAt := time.Unix(int64(exp), 0)
+
+	if jti == "" {
+		return errors.New("token has no id")
+	}
+
+	row := models.RevokedToken{
+		Jti:       jti,
+		UserId:    uint(userID),
+		ExpiresAt: expiresAt,
+	}
+
+	if err := database.GetDB().Create(&row).Error; err != nil {
+		return err
+	}
+
+	mu.Lock()
+	tokens[jti] = expiresAt
+	mu.Unlock()
+
+	return nil
+}
+
+// RevokeSession blocks the access tokens of a login session. The session row
+// has to be terminated by the caller; other instances pick it up from there.
+func RevokeSession(familyID string) {
+	mu.Lock()
+	sessions[familyID] = time.Now().Add(helpers.AccessTokenTTL())
+	mu.Unlock()
+}
+
+// RevokeUser blocks every access token issued to the user so far and revokes
+// all of the user's refresh tokens and personal access tokens.
+func RevokeUser(db *gorm.DB, userID uint) error {
+	now := time.Now()
+	row := models.RevokedToken{
+		UserId:        userID,
+		RevokedBefore: &now,
+		ExpiresAt:     now.Add(helpers.AccessTokenTTL()),
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&row).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Session{}).
+			Where("user_id = ? AND terminated_at IS NULL", userID).
+			Update("terminated_at", now).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.PersonalAccessToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error
+	})
+
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	users[userID] = now
+	mu.Unlock()
+
+	return nil
+}
+
+// IsRevoked reports whether the token was revoked on its own, belongs to a
+// terminated session or was issued no later than the user's last revoke-all.
+// iat only has second precision, so the millisecond iat_ms claim is compared
+// when the token carries it.
+func IsRevoked(claims jwt.MapClaims) bool {
+	jti, _ := claims["jti"].(string)
+	sid, _ := claims["sid"].(string)
+	userID, _ := claims["id"].(float64)
+	iat, _ := claims["iat"].(float64)
+	iatMs, hasMs := claims["iat_ms"].(float64)
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if _, ok := tokens[jti]; ok {
+		return true
+	}
+
+	if _, ok := sessions[sid]; ok && sid != "" {
+		return true
+	}
+
+	before, ok := users[uint(userID)]
+	if !ok {
+		return false
+	}
+
+	if hasMs {
+		return int64(iatMs) <= before.UnixMilli()
+	}
+
+	return int64(iat) <= before.Unix()
+}
@@ -2,7 +2,12 @@ package router
 
 import (
 	"final-project/controllers"
+	"final-project/helpers"
 	"final-project/middlewares"
+	"final-project/models"
+	"log"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -11,29 +16,107 @@ import (
 func StartApp() *gin.Engine {
 	r := gin.Default()
 
+	// ClientIP only honours X-Forwarded-For from these proxies, otherwise the
+	// per-IP login limits could be dodged with a forged header.
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %s", err)
+	}
+
 	r.GET("/docs/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
+	r.GET("/.well-known/jwks.json", controllers.JWKS)
+	r.GET("/media/:key", controllers.MediaGet)
 	userRouter := r.Group("/users")
 	{
 		userRouter.POST("/register", controllers.UserRegister)
 		userRouter.POST("/login", controllers.UserLogin)
-		userRouter.PUT("/:userId", middlewares.Authentication(), middlewares.ProfileAuthorization(), controllers.UserUpdate)
-		userRouter.DELETE("/", middlewares.Authentication(), middlewares.ProfileAuthorization(), controllers.UserDelete)
+		userRouter.POST("/login/mfa", controllers.UserLoginMFA)
+		userRouter.POST("/refresh", controllers.UserRefresh)
+		userRouter.GET("/oauth/providers", controllers.OAuthProviders)
+		userRouter.GET("/oauth/:provider/authorize", controllers.OAuthAuthorize)
+		userRouter.GET("/oauth/:provider/callback", controllers.OAuthCallback)
+		userRouter.POST("/logout", middlewares.Authentication(), middlewares.NoPersonalAccessToken(), controllers.UserLogout)
+		userRouter.POST("/logout-all", middlewares.Authentication(), middlewares.NoPersonalAccessToken(), controllers.UserLogoutAll)
+		userRouter.POST("/password/forgot", controllers.UserForgotPassword)
+		userRouter.POST("/password/reset", controllers.UserResetPassword)
+		userRouter.GET("/verify", controllers.UserVerifyEmail)
+		userRouter.POST("/verify/resend", middlewares.Authentication(), middlewares.NoPersonalAccessToken(), controllers.UserResendVerification)
+		userRouter.GET("/me", middlewares.Authentication(), middlewares.Scope("users"), controllers.UserGetMe)
+		userRouter.GET("/by-username/:username", middlewares.Authentication(), middlewares.Scope("users"), controllers.UserGetProfileByUsername)
+		userRouter.GET("/:userId", middlewares.Authentication(), middlewares.Scope("users"), controllers.UserGetProfile)
+		userRouter.POST("/:userId/follow", middlewares.Authentication(), middlewares.Scope("users"), controllers.UserFollow)
+		userRouter.DELETE("/:userId/follow", middlewares.Authentication(), middlewares.Scope("users"), controllers.UserUnfollow)
+		userRouter.GET("/:userId/followers", middlewares.Authentication(), middlewares.Scope("users"), controllers.UserFollowers)
+		userRouter.GET("/:userId/following", middlewares.Authentication(), middlewares.Scope("users"), controllers.UserFollowing)
+		userRouter.GET("/:userId/albums", middlewares.Authentication(), middlewares.Scope("photos"), controllers.UserAlbums)
+		userRouter.PUT("/:userId", middlewares.Authentication(), middlewares.NoPersonalAccessToken(), middlewares.ProfileAuthorization(), controllers.UserUpdate)
+		userRouter.DELETE("/", middlewares.Authentication(), middlewares.NoPersonalAccessToken(), middlewares.ProfileAuthorization(), controllers.UserDelete)
+	}
+
+	meRouter := r.Group("/users/me")
+	{
+		meRouter.Use(middlewares.Authentication(), middlewares.NoPersonalAccessToken())
+		meRouter.PUT("/password", controllers.UserChangePassword)
+		meRouter.POST("/2fa/enroll", controllers.UserEnrollTOTP)
+		meRouter.POST("/2fa/confirm", controllers.UserConfirmTOTP)
+		meRouter.POST("/2fa/disable", controllers.UserDisableTOTP)
+		meRouter.POST("/tokens", controllers.PersonalAccessTokenCreate)
+		meRouter.GET("/tokens", controllers.PersonalAccessTokenList)
+		meRouter.DELETE("/tokens/:tokenId", controllers.PersonalAccessTokenRevoke)
+		meRouter.GET("/sessions", controllers.SessionList)
+		meRouter.DELETE("/sessions/:sessionId", controllers.SessionTerminate)
+		meRouter.GET("/identities", controllers.IdentityList)
+		meRouter.POST("/identities/:provider", controllers.IdentityLink)
+		meRouter.DELETE("/identities/:identityId", controllers.IdentityUnlink)
+	}
+
+	adminRouter := r.Group("/admin")
+	{
+		adminRouter.Use(middlewares.Authentication(), middlewares.NoPersonalAccessToken())
+		adminRouter.POST("/users/:userId/unlock", middlewares.RoleAuthorization(models.RoleAdmin), controllers.AdminUnlockUser)
+		adminRouter.PUT("/users/:userId/role", middlewares.RoleAuthorization(models.RoleAdmin), controllers.AdminUpdateRole)
+		adminRouter.GET("/audit-logs", middlewares.RoleAuthorization(models.RoleAdmin), controllers.AdminAuditLogs)
+		adminRouter.GET("/photos/:photoId/similar", middlewares.RoleAuthorization(models.RoleModerator, models.RoleAdmin), controllers.AdminSimilarPhotos)
+	}
+
+	r.GET("/feed", middlewares.Authentication(), middlewares.Scope("photos"), controllers.Feed)
+	r.GET("/search", middlewares.Authentication(), controllers.Search)
+
+	tagRouter := r.Group("/tags")
+	{
+		tagRouter.Use(middlewares.Authentication(), middlewares.Scope("photos"))
+		tagRouter.GET("/trending", controllers.TrendingTags)
+		tagRouter.GET("/:tag/photos", controllers.TagPhotos)
 	}
 
 	photoRouter := r.Group("/photos")
 	{
-		photoRouter.Use(middlewares.Authentication())
-		photoRouter.POST("/", controllers.PhotoCreate)
+		photoRouter.Use(middlewares.Authentication(), middlewares.Scope("photos"))
+		photoRouter.POST("/", middlewares.VerifiedEmail(), controllers.PhotoCreate)
 		photoRouter.GET("/", controllers.PhotoGetAll)
 		photoRouter.GET("/:photoId", controllers.PhotoGetByID)
+		photoRouter.POST("/:photoId/likes", middlewares.VerifiedEmail(), controllers.PhotoLike)
+		photoRouter.DELETE("/:photoId/likes", controllers.PhotoUnlike)
+		photoRouter.GET("/:photoId/likes", controllers.PhotoLikes)
 		photoRouter.PUT("/:photoId", middlewares.PhotoAuthorization(), controllers.PhotoUpdate)
 		photoRouter.DELETE("/:photoId", middlewares.PhotoAuthorization(), controllers.PhotoDelete)
 	}
 
+	albumRouter := r.Group("/albums")
+	{
+		albumRouter.Use(middlewares.Authentication(), middlewares.Scope("photos"))
+		albumRouter.POST("/", middlewares.VerifiedEmail(), controllers.AlbumCreate)
+		albumRouter.GET("/:albumId", controllers.AlbumGetByID)
+		albumRouter.PUT("/:albumId", middlewares.AlbumAuthorization(), controllers.AlbumUpdate)
+		albumRouter.DELETE("/:albumId", middlewares.AlbumAuthorization(), controllers.AlbumDelete)
+		albumRouter.POST("/:albumId/photos", middlewares.AlbumAuthorization(), controllers.AlbumAddPhoto)
+		albumRouter.PUT("/:albumId/photos", middlewares.AlbumAuthorization(), controllers.AlbumReorder)
+		albumRouter.DELETE("/:albumId/photos/:photoId", middlewares.AlbumAuthorization(), controllers.AlbumRemovePhoto)
+	}
+
 	commentRouter := r.Group("/comments")
 	{
-		commentRouter.Use(middlewares.Authentication())
-		commentRouter.POST("/", controllers.CommentCreate)
+		commentRouter.Use(middlewares.Authentication(), middlewares.Scope("comments"))
+		commentRouter.POST("/", middlewares.VerifiedEmail(), controllers.CommentCreate)
 		commentRouter.GET("/", controllers.CommentList)
 		commentRouter.GET("/:commentId", controllers.CommentByID)
 		commentRouter.PUT("/:commentId", middlewares.CommentAuthorization(), controllers.CommentUpdate)
@@ -42,8 +125,8 @@ func StartApp() *gin.Engine {
 
 	socialmediasRouter := r.Group("/socialmedias")
 	{
-		socialmediasRouter.Use(middlewares.Authentication())
-		socialmediasRouter.POST("/", controllers.SocialMediaCreate)
+		socialmediasRouter.Use(middlewares.Authentication(), middlewares.Scope("socialmedias"))
+		socialmediasRouter.POST("/", middlewares.VerifiedEmail(), controllers.SocialMediaCreate)
 		socialmediasRouter.GET("/", controllers.SocialMediaList)
 		socialmediasRouter.GET("/:socialMediaId", controllers.GetSocialMediaByID) 
 		socialmediasRouter.PUT("/:socialMediaId", middlewares.SocialMediaAuthorization(), controllers.SocialMediaUpdate)
@@ -53,3 +136,17 @@ func StartApp() *gin.Engine {
 
 	return r
 }
+
+// trustedProxies reads TRUSTED_PROXIES, a comma separated list of proxy IPs
+// or CIDRs. Without it no proxy is trusted and the peer address is used.
+func trustedProxies() []string {
+	proxies := []string{}
+
+	for _, proxy := range strings.Split(helpers.GetEnv("TRUSTED_PROXIES", ""), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+
+	return proxies
+}
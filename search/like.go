@@ -0,0 +1,63 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// LikeSearcher matches terms with LIKE, for databases without full text
+// search. Every term matches as a substring; newer documents rank first.
+type LikeSearcher struct{}
+
+type likeRow struct {
+	ID   uint
+	Text string
+}
+
+func (s LikeSearcher) Search(db *gorm.DB, req Request) ([]Hit, error) {
+	doc, ok := documents[req.Kind]
+	if !ok {
+		return nil, ErrInvalidKind
+	}
+
+	if len(req.Terms) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	text := "COALESCE(" + strings.Join(doc.fields, ", '') || ' ' || COALESCE(") + ", '')"
+	query := db.Table(doc.table).Select("id, " + text + " AS text")
+
+	for _, term := range req.Terms {
+		query = query.Where("LOWER("+text+") LIKE ? ESCAPE '\\'", "%"+escapeLike(term)+"%")
+	}
+
+	rows := []likeRow{}
+	if err := query.Order("id desc").Limit(req.Limit).Offset(req.Offset).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, len(rows))
+	for i, row := range rows {
+		hits[i] = Hit{ID: row.ID, Rank: 0, Snippet: highlight(row.Text, req.Terms)}
+	}
+
+	return hits, nil
+}
+
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+}
+
+// highlight marks case-insensitive occurrences of the terms like ts_headline does.
+func highlight(text string, terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+
+	pattern := regexp.MustCompile("(?i)(" + strings.Join(quoted, "|") + ")")
+
+	return finishSnippet(pattern.ReplaceAllString(text, markStart+"$1"+markStop))
+}
@@ -0,0 +1,101 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// document describes the searchable text of one table. Fields are weighted
+// A, B, ... in order, so a match in a photo title ranks above the caption.
+type document struct {
+	table  string
+	fields []string
+}
+
+var documents = map[string]document{
+	KindPhotos:   {table: "photos", fields: []string{"title", "caption"}},
+	KindComments: {table: "comments", fields: []string{"message"}},
+	KindUsers:    {table: "users", fields: []string{"username"}},
+}
+
+// PostgresSearcher ranks documents with ts_rank_cd over generated tsvector
+// columns and highlights matches with ts_headline.
+type PostgresSearcher struct {
+	Config string
+}
+
+// Migrate adds a generated search_vector column with a GIN index to every
+// searchable table. It is a no-op on databases other than PostgreSQL.
+func Migrate(db *gorm.DB) error {
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	config := language()
+
+	for _, doc := range documents {
+		parts := make([]string, len(doc.fields))
+		for i, field := range doc.fields {
+			parts[i] = fmt.Sprintf("setweight(to_tsvector('%s', coalesce(%s, '')), '%c')", config, field, 'A'+i)
+		}
+
+		statements := []string{
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (%s) STORED", doc.table, strings.Join(parts, " || ")),
+			fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_search_vector ON %s USING GIN (search_vector)", doc.table, doc.table),
+		}
+
+		for _, statement := range statements {
+			if err := db.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s PostgresSearcher) Search(db *gorm.DB, req Request) ([]Hit, error) {
+	doc, ok := documents[req.Kind]
+	if !ok {
+		return nil, ErrInvalidKind
+	}
+
+	if len(req.Terms) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	hits := []Hit{}
+	query := tsquery(req.Terms)
+	text := "coalesce(" + strings.Join(doc.fields, ", '') || ' ' || coalesce(") + ", '')"
+	options := fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=30, MinWords=10, MaxFragments=2", markStart, markStop)
+
+	err := db.Table(doc.table).
+		Select(fmt.Sprintf("id, ts_rank_cd(search_vector, to_tsquery('%[1]s', ?)) AS rank, ts_headline('%[1]s', %[2]s, to_tsquery('%[1]s', ?), ?) AS snippet", s.Config, text), query, query, options).
+		Where(fmt.Sprintf("search_vector @@ to_tsquery('%s', ?)", s.Config), query).
+		Order("rank desc, id desc").
+		Limit(req.Limit).
+		Offset(req.Offset).
+		Scan(&hits).Error
+
+	for i := range hits {
+		hits[i].Snippet = finishSnippet(hits[i].Snippet)
+	}
+
+	return hits, err
+}
+
+// tsquery requires every term and lets the last one match as a prefix, so
+// results show up while the user is still typing.
+func tsquery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = term
+		if i == len(terms)-1 {
+			parts[i] += ":*"
+		}
+	}
+
+	return strings.Join(parts, " & ")
+}
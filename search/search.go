@@ -0,0 +1,149 @@
+package search
+
+import (
+	"encoding/base64"
+	"errors"
+	"final-project/helpers"
+	"html"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of documents that can be searched.
+const (
+	KindPhotos   = "photos"
+	KindComments = "comments"
+	KindUsers    = "users"
+)
+
+var (
+	ErrInvalidKind   = errors.New("type must be one of photos, comments or users")
+	ErrEmptyQuery    = errors.New("q must contain at least one letter or digit")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Request is one page of a search. Terms are the words of the query; every
+// term has to match, and the last one also matches as a prefix.
+type Request struct {
+	Kind   string
+	Terms  []string
+	Limit  int
+	Offset int
+}
+
+// Hit is a matching document. Snippet is the matched text with the terms
+// wrapped in <mark></mark>.
+type Hit struct {
+	ID      uint    `json:"id"`
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// Searcher finds documents of one kind. PostgresSearcher uses full text
+// search; LikeSearcher works on any database.
+type Searcher interface {
+	Search(db *gorm.DB, req Request) ([]Hit, error)
+}
+
+var (
+	searcher Searcher
+	once     sync.Once
+)
+
+// GetSearcher returns the searcher selected by SEARCH_DRIVER ("postgres" or
+// "like"). Databases other than PostgreSQL always use "like".
+func GetSearcher(db *gorm.DB) Searcher {
+	once.Do(func() {
+		driver := helpers.GetEnv("SEARCH_DRIVER", "postgres")
+
+		if db.Dialector.Name() != "postgres" {
+			driver = "like"
+		}
+
+		switch driver {
+		case "postgres":
+			searcher = PostgresSearcher{Config: language()}
+		case "like":
+			searcher = LikeSearcher{}
+		default:
+			log.Fatalf("Unknown SEARCH_DRIVER %q", driver)
+		}
+	})
+
+	return searcher
+}
+
+// SetSearcher replaces the configured searcher, e.g. with a LikeSearcher in tests.
+func SetSearcher(s Searcher) {
+	once.Do(func() {})
+	searcher = s
+}
+
+var languagePattern = regexp.MustCompile(`^[a-z_]+$`)
+
+// language is the text search configuration from SEARCH_LANGUAGE. It ends up
+// in DDL, so only plain identifiers are accepted.
+func language() string {
+	config := helpers.GetEnv("SEARCH_LANGUAGE", "simple")
+	if !languagePattern.MatchString(config) {
+		log.Fatalf("Invalid SEARCH_LANGUAGE %q", config)
+	}
+
+	return config
+}
+
+// ValidKind reports whether kind can be searched.
+func ValidKind(kind string) bool {
+	return kind == KindPhotos || kind == KindComments || kind == KindUsers
+}
+
+// Terms splits a query into lower case words of letters and digits, so it can
+// be used in a tsquery or a LIKE pattern without escaping surprises.
+func Terms(q string) []string {
+	terms := []string{}
+
+	for _, field := range strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		terms = append(terms, field)
+	}
+
+	return terms
+}
+
+// Matches are delimited with control characters first and only turned into
+// <mark></mark> after the text is escaped, so snippets are safe to render as HTML.
+const (
+	markStart = "\x02"
+	markStop  = "\x03"
+)
+
+func finishSnippet(snippet string) string {
+	return strings.NewReplacer(markStart, "<mark>", markStop, "</mark>").Replace(html.EscapeString(snippet))
+}
+
+// EncodeCursor and DecodeCursor turn an offset into an opaque cursor. Results
+// are ordered by rank, which has no stable key to page by.
+func EncodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+func DecodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), "offset:") {
+		return 0, ErrInvalidCursor
+	}
+
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), "offset:"))
+	if err != nil || offset < 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return offset, nil
+}
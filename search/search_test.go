@@ -0,0 +1,82 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTerms(t *testing.T) {
+	tests := map[string][]string{
+		"Sunset Beach":           {"sunset", "beach"},
+		"  #sunset, beach!! ":    {"sunset", "beach"},
+		"it's 50%_off & more":    {"it", "s", "50", "off", "more"},
+		"Ünïcode straße":         {"ünïcode", "straße"},
+		"'; DROP TABLE users --": {"drop", "table", "users"},
+		"!!! ---":                {},
+	}
+
+	for q, want := range tests {
+		if got := Terms(q); !reflect.DeepEqual(got, want) {
+			t.Errorf("Terms(%q) = %q, want %q", q, got, want)
+		}
+	}
+}
+
+func TestTsquery(t *testing.T) {
+	tests := []struct {
+		terms []string
+		want  string
+	}{
+		{[]string{"sun"}, "sun:*"},
+		{[]string{"sunset", "bea"}, "sunset & bea:*"},
+		{[]string{"a", "b", "c"}, "a & b & c:*"},
+	}
+
+	for _, tt := range tests {
+		if got := tsquery(tt.terms); got != tt.want {
+			t.Errorf("tsquery(%q) = %q, want %q", tt.terms, got, tt.want)
+		}
+	}
+}
+
+func TestCursor(t *testing.T) {
+	for _, offset := range []int{0, 20, 12345} {
+		got, err := DecodeCursor(EncodeCursor(offset))
+		if err != nil || got != offset {
+			t.Errorf("DecodeCursor(EncodeCursor(%d)) = %d, %v", offset, got, err)
+		}
+	}
+
+	for _, cursor := range []string{"", "not base64!", EncodeCursor(-1), "b2Zmc2V0Onh5eg", "aWQ6MTA"} {
+		if _, err := DecodeCursor(cursor); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if got := escapeLike(`50%_off\`); got != `50\%\_off\\` {
+		t.Fatalf("escapeLike = %q", got)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	got := highlight(`Sunset at <b>the</b> SUNSET beach`, []string{"sunset", "beach"})
+	want := `<mark>Sunset</mark> at &lt;b&gt;the&lt;/b&gt; <mark>SUNSET</mark> <mark>beach</mark>`
+
+	if got != want {
+		t.Fatalf("highlight = %q, want %q", got, want)
+	}
+}
+
+func TestValidKind(t *testing.T) {
+	for _, kind := range []string{KindPhotos, KindComments, KindUsers} {
+		if !ValidKind(kind) {
+			t.Errorf("ValidKind(%q) = false", kind)
+		}
+	}
+
+	if ValidKind("socialmedias") {
+		t.Error("ValidKind accepted an unknown kind")
+	}
+}
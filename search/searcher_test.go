@@ -0,0 +1,220 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"final-project/database"
+	"final-project/models"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var errRecorded = errors.New("recorded")
+
+// recorder is a connection that keeps the queries it gets instead of running
+// them, so the SQL of a search can be checked without a database.
+type recorder struct {
+	dialector  gorm.Dialector
+	statements []string
+}
+
+func (r *recorder) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errRecorded
+}
+
+func (r *recorder) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, errRecorded
+}
+
+func (r *recorder) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	r.statements = append(r.statements, r.dialector.Explain(query, args...))
+	return nil, errRecorded
+}
+
+func (r *recorder) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func dryRun(t *testing.T) (*gorm.DB, *recorder) {
+	t.Helper()
+
+	recorder := &recorder{}
+	recorder.dialector = postgres.New(postgres.Config{Conn: recorder})
+
+	db, err := gorm.Open(recorder.dialector, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db, recorder
+}
+
+func TestLikeSearcherQuery(t *testing.T) {
+	db, recorder := dryRun(t)
+
+	_, err := LikeSearcher{}.Search(db, Request{Kind: KindPhotos, Terms: []string{"sun", "50%_off"}, Limit: 21, Offset: 40})
+	if err != errRecorded {
+		t.Fatalf("error = %v, want the recorded query", err)
+	}
+
+	if len(recorder.statements) != 1 {
+		t.Fatalf("ran %d statements, want 1", len(recorder.statements))
+	}
+
+	query := recorder.statements[0]
+	for _, part := range []string{
+		`FROM "photos"`,
+		`LOWER(COALESCE(title, '') || ' ' || COALESCE(caption, '')) LIKE '%sun%' ESCAPE '\'`,
+		`LIKE '%50\%\_off%' ESCAPE '\'`,
+		"ORDER BY id desc LIMIT 21 OFFSET 40",
+	} {
+		if !strings.Contains(query, part) {
+			t.Errorf("query %q does not contain %q", query, part)
+		}
+	}
+}
+
+func TestLikeSearcherRejectsBadRequests(t *testing.T) {
+	db, _ := dryRun(t)
+
+	if _, err := (LikeSearcher{}).Search(db, Request{Kind: "albums", Terms: []string{"sun"}}); err != ErrInvalidKind {
+		t.Errorf("unknown kind: error = %v, want ErrInvalidKind", err)
+	}
+
+	if _, err := (LikeSearcher{}).Search(db, Request{Kind: KindUsers}); err != ErrEmptyQuery {
+		t.Errorf("no terms: error = %v, want ErrEmptyQuery", err)
+	}
+}
+
+func TestPostgresSearcherQuery(t *testing.T) {
+	db, recorder := dryRun(t)
+
+	_, err := PostgresSearcher{Config: "simple"}.Search(db, Request{Kind: KindComments, Terms: []string{"nice", "pho"}, Limit: 11})
+	if err != errRecorded || len(recorder.statements) != 1 {
+		t.Fatalf("error = %v, want one recorded query", err)
+	}
+
+	query := recorder.statements[0]
+	for _, part := range []string{
+		`FROM "comments"`,
+		`search_vector @@ to_tsquery('simple', 'nice & pho:*')`,
+		"ORDER BY rank desc, id desc LIMIT 11",
+	} {
+		if !strings.Contains(query, part) {
+			t.Errorf("query %q does not contain %q", query, part)
+		}
+	}
+}
+
+var (
+	dbOnce sync.Once
+	dbErr  error
+)
+
+// testDB connects to TEST_DATABASE_URL and adds the search columns.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	dbOnce.Do(func() {
+		if dbErr = database.Connect(dsn); dbErr == nil {
+			dbErr = Migrate(database.GetDB())
+		}
+	})
+
+	if dbErr != nil {
+		t.Fatalf("connect: %s", dbErr)
+	}
+
+	return database.GetDB()
+}
+
+// seed stores a user, a photo and a comment whose text contains word.
+func seed(t *testing.T, db *gorm.DB, word string) (models.User, models.Photo, models.Comment) {
+	t.Helper()
+
+	user := models.User{
+		Username:        word + "user",
+		Email:           word + "@example.com",
+		Password:        "correct horse battery",
+		ProfileImageURL: "https://example.com/avatar.png",
+		Age:             20,
+	}
+
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %s", err)
+	}
+
+	t.Cleanup(func() { db.Delete(&models.User{}, user.ID) })
+
+	photo := models.Photo{Title: "Sunset " + word, Caption: "At the beach", PhotoUrl: "https://example.com/p.jpg", UserId: user.ID}
+	if err := db.Create(&photo).Error; err != nil {
+		t.Fatalf("create photo: %s", err)
+	}
+
+	comment := models.Comment{Message: "Lovely " + word + " colours", PhotoId: photo.ID, UserId: user.ID}
+	if err := db.Create(&comment).Error; err != nil {
+		t.Fatalf("create comment: %s", err)
+	}
+
+	return user, photo, comment
+}
+
+func testSearcher(t *testing.T, searcher Searcher) {
+	db := testDB(t)
+	word := "zq" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	user, photo, comment := seed(t, db, word)
+
+	tests := []struct {
+		kind  string
+		q     string
+		want  uint
+		match string
+	}{
+		{KindPhotos, "sunset " + word, photo.ID, "<mark>" + word + "</mark>"},
+		{KindComments, word[:len(word)-2], comment.ID, ""},
+		{KindUsers, strings.ToUpper(word), user.ID, ""},
+	}
+
+	for _, tt := range tests {
+		hits, err := searcher.Search(db, Request{Kind: tt.kind, Terms: Terms(tt.q), Limit: 10})
+		if err != nil {
+			t.Fatalf("%s %q: %s", tt.kind, tt.q, err)
+		}
+
+		if len(hits) != 1 || hits[0].ID != tt.want {
+			t.Fatalf("%s %q: hits = %+v, want id %d", tt.kind, tt.q, hits, tt.want)
+		}
+
+		if !strings.Contains(hits[0].Snippet, tt.match) {
+			t.Errorf("%s %q: snippet %q does not contain %q", tt.kind, tt.q, hits[0].Snippet, tt.match)
+		}
+	}
+
+	// Every term has to match.
+	hits, err := searcher.Search(db, Request{Kind: KindPhotos, Terms: Terms(word + " mountains"), Limit: 10})
+	if err != nil || len(hits) != 0 {
+		t.Fatalf("search with an unmatched term: %+v, %v", hits, err)
+	}
+}
+
+func TestLikeSearcher(t *testing.T) {
+	testSearcher(t, LikeSearcher{})
+}
+
+func TestPostgresSearcher(t *testing.T) {
+	testSearcher(t, PostgresSearcher{Config: "simple"})
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"final-project/helpers"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound        = errors.New("file not found")
+	ErrInvalidKey      = errors.New("invalid file key")
+	ErrTooLarge        = errors.New("file is too large")
+	ErrUnsupportedType = errors.New("file must be a JPEG, PNG, GIF or WebP image")
+)
+
+// Object is a stored file. Body has to be closed by the caller; for local
+// files it is also an io.ReadSeeker.
+type Object struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64
+	ModTime     time.Time
+}
+
+// Storage keeps uploaded files. Keys are content addressed, so Put may skip
+// files that are already stored. LocalStorage writes to a directory and
+// S3Storage to any S3 compatible bucket.
+type Storage interface {
+	Put(ctx context.Context, key, contentType string, body io.ReadSeeker, size int64) error
+	Get(ctx context.Context, key string) (*Object, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Accepted image types, by the type sniffed from their content.
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+var keyPattern = regexp.MustCompile(`^[0-9a-f]{64}\.(jpg|png|gif|webp)$`)
+
+var (
+	storage Storage
+	once    sync.Once
+)
+
+// GetStorage returns the storage selected by STORAGE_DRIVER ("local" or "s3").
+func GetStorage() Storage {
+	once.Do(func() {
+		if storage != nil {
+			return
+		}
+
+		switch helpers.GetEnv("STORAGE_DRIVER", "local") {
+		case "local":
+			storage = NewLocalStorage(helpers.GetEnv("STORAGE_DIR", "uploads"))
+		case "s3":
+			storage = NewS3Storage(
+				helpers.GetEnv("S3_ENDPOINT", "https://s3.amazonaws.com"),
+				helpers.GetEnv("S3_REGION", "us-east-1"),
+				helpers.GetEnv("S3_BUCKET", ""),
+				helpers.GetEnv("S3_ACCESS_KEY_ID", ""),
+				helpers.GetEnv("S3_SECRET_ACCESS_KEY", ""),
+				helpers.GetEnvBool("S3_PATH_STYLE", true),
+			)
+		default:
+			log.Fatalf("Unknown STORAGE_DRIVER %q", helpers.GetEnv("STORAGE_DRIVER", ""))
+		}
+	})
+
+	return storage
+}
+
+// SetStorage replaces the configured storage, e.g. with a LocalStorage in a
+// temporary directory in tests.
+func SetStorage(s Storage) {
+	once.Do(func() {})
+	storage = s
+}
+
+// MaxUploadSize is UPLOAD_MAX_BYTES, 10 MiB by default.
+func MaxUploadSize() int64 {
+	return int64(helpers.GetEnvInt("UPLOAD_MAX_BYTES", 10<<20))
+}
+
+// Save checks that body is an accepted image no larger than MaxUploadSize and
+// stores it under the SHA-256 of its content, so uploading the same file
+// twice stores it once.
+func Save(ctx context.Context, s Storage, body io.ReadSeeker) (key, contentType string, err error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(body, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		if err == io.EOF {
+			return "", "", ErrUnsupportedType
+		}
+
+		return "", "", err
+	}
+
+	contentType = http.DetectContentType(head[:n])
+	ext, ok := extensions[contentType]
+	if !ok {
+		return "", "", ErrUnsupportedType
+	}
+
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return "", "", err
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, io.LimitReader(body, MaxUploadSize()+1))
+	if err != nil {
+		return "", "", err
+	}
+
+	if size > MaxUploadSize() {
+		return "", "", ErrTooLarge
+	}
+
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return "", "", err
+	}
+
+	key = hex.EncodeToString(hash.Sum(nil)) + ext
+	if err := s.Put(ctx, key, contentType, body, size); err != nil {
+		return "", "", err
+	}
+
+	return key, contentType, nil
+}
+
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key)
+}
+
+// ContentType returns the type of a stored file from the extension of its key.
+func ContentType(key string) string {
+	ext := path.Ext(key)
+	for contentType, candidate := range extensions {
+		if candidate == ext {
+			return contentType
+		}
+	}
+
+	return "application/octet-stream"
+}
+
+// URL is where clients download a file: MEDIA_URL/<key>, which defaults to
+// the GET /media/:key route of this API.
+func URL(key string) string {
+	base := helpers.GetEnv("MEDIA_URL", strings.TrimRight(helpers.GetEnv("APP_URL", "http://localhost:8080"), "/")+"/media")
+
+	return strings.TrimRight(base, "/") + "/" + key
+}